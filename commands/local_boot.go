@@ -0,0 +1,140 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/symfony-cli/console"
+	"github.com/symfony-cli/symfony-cli/local/projects"
+	"github.com/symfony-cli/symfony-cli/util"
+	"github.com/symfony-cli/terminal"
+)
+
+const systemdUnitName = "symfony-cli.service"
+
+var localBootCmd = &console.Command{
+	Category: "local",
+	Name:     "boot",
+	Usage:    "Start the proxy and the local web servers enabled via server:autostart",
+	Action: func(c *console.Context) error {
+		autostart, err := projects.LoadAutostart(util.GetHomeDir())
+		if err != nil {
+			return err
+		}
+		dirs := autostart.Dirs()
+		if len(dirs) == 0 {
+			terminal.Printfln("No projects to start, enable some via <info>%s server:autostart enable</>", c.App.HelpName)
+			return nil
+		}
+
+		binary, err := console.CurrentBinaryPath()
+		if err != nil {
+			return err
+		}
+
+		// each command is run as a sub-process to be started as it would be
+		// from a terminal (in the background and via the supervisor if running)
+		run := func(args ...string) error {
+			cmd := exec.Command(binary, args...)
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+			return cmd.Run()
+		}
+
+		failed := 0
+		terminal.Println("Starting the proxy")
+		if err := run(localProxyStartCmd.FullName()); err != nil {
+			terminal.Printfln("<error>Unable to start the proxy: %s</>", err)
+			failed++
+		}
+		for _, dir := range dirs {
+			terminal.Printfln("Starting the local web server of <info>%s</>", dir)
+			if _, err := os.Stat(dir); err != nil {
+				terminal.Printfln("<warning>WARNING</> Skipping %s as the directory does not exist anymore", dir)
+				continue
+			}
+			if err := run(localServerStartCmd.FullName(), "--daemon", "--dir", dir); err != nil {
+				terminal.Printfln("<error>Unable to start the local web server of %s: %s</>", dir, err)
+				failed++
+			}
+		}
+
+		if failed > 0 {
+			return console.Exit("", 1)
+		}
+		return nil
+	},
+}
+
+// installSystemdUnit installs a systemd user unit running "local:boot" on login
+func installSystemdUnit() error {
+	userHomeDir, err := homedir.Dir()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	unitFile := filepath.Join(userHomeDir, ".config", "systemd", "user", systemdUnitName)
+
+	binary, err := console.CurrentBinaryPath()
+	if err != nil {
+		return err
+	}
+	unit := fmt.Sprintf(`[Unit]
+Description=Symfony CLI local web servers
+After=network.target
+
+[Service]
+Type=oneshot
+RemainAfterExit=yes
+ExecStart=%s %s
+
+[Install]
+WantedBy=default.target
+`, binary, localBootCmd.FullName())
+
+	// the unit is rewritten when the binary has moved (after an upgrade for instance)
+	current, err := ioutil.ReadFile(unitFile)
+	if err == nil && string(current) == unit {
+		return nil
+	}
+	updated := err == nil
+
+	if err := os.MkdirAll(filepath.Dir(unitFile), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := ioutil.WriteFile(unitFile, []byte(unit), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if updated {
+		terminal.Printfln("The systemd user unit in %s has been updated", unitFile)
+		terminal.Printfln("Reload it via <info>systemctl --user daemon-reload</>")
+		return nil
+	}
+	terminal.Printfln("A systemd user unit has been created in %s", unitFile)
+	terminal.Printfln("Enable it via <info>systemctl --user daemon-reload && systemctl --user enable %s</>", systemdUnitName)
+	return nil
+}
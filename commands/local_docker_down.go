@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package commands
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/symfony-cli/console"
+	"github.com/symfony-cli/symfony-cli/envs"
+	"github.com/symfony-cli/terminal"
+)
+
+var localDockerDownCmd = &console.Command{
+	Category: "local",
+	Name:     "docker:down",
+	Aliases:  []*console.Alias{{Name: "docker:down"}},
+	Usage:    "Stop and remove the Docker Compose services of the project",
+	Flags: []console.Flag{
+		dirFlag,
+		&console.StringSliceFlag{Name: "profile", Usage: "Docker Compose profile to enable (COMPOSE_PROFILES is used by default)"},
+		&console.BoolFlag{Name: "volumes", Usage: "Remove the volumes of the services as well"},
+	},
+	Action: func(c *console.Context) error {
+		projectDir, err := getProjectDir(c.String("dir"))
+		if err != nil {
+			return err
+		}
+		ui := terminal.SymfonyStyle(terminal.Stdout, terminal.Stdin)
+		env, err := envs.NewLocal(projectDir, terminal.IsDebug())
+		if err != nil {
+			return err
+		}
+		args := append(profileArgs(c.StringSlice("profile")), "down", "--remove-orphans")
+		if c.Bool("volumes") {
+			args = append(args, "--volumes")
+		}
+		cmd, err := env.ComposeCommand(args...)
+		if err != nil {
+			return err
+		}
+		if err := cmd.Run(); err != nil {
+			return errors.Wrap(err, "unable to stop the Docker Compose services")
+		}
+		os.Remove(dockerServicesFile(projectDir))
+		ui.Success("Docker Compose services stopped successfully")
+		return nil
+	},
+}
@@ -0,0 +1,52 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package commands
+
+import (
+	"github.com/pkg/errors"
+	"github.com/symfony-cli/console"
+	"github.com/symfony-cli/symfony-cli/envs"
+	"github.com/symfony-cli/terminal"
+)
+
+var localDockerPsCmd = &console.Command{
+	Category: "local",
+	Name:     "docker:ps",
+	Aliases:  []*console.Alias{{Name: "docker:ps"}},
+	Usage:    "List the Docker Compose services of the project",
+	Flags: []console.Flag{
+		dirFlag,
+	},
+	Action: func(c *console.Context) error {
+		projectDir, err := getProjectDir(c.String("dir"))
+		if err != nil {
+			return err
+		}
+		env, err := envs.NewLocal(projectDir, terminal.IsDebug())
+		if err != nil {
+			return err
+		}
+		cmd, err := env.ComposeCommand("ps")
+		if err != nil {
+			return err
+		}
+		return errors.Wrap(cmd.Run(), "unable to list the Docker Compose services")
+	},
+}
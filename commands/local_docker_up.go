@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/symfony-cli/console"
+	"github.com/symfony-cli/symfony-cli/envs"
+	"github.com/symfony-cli/symfony-cli/local/pid"
+	"github.com/symfony-cli/symfony-cli/util"
+	"github.com/symfony-cli/terminal"
+)
+
+var localDockerUpCmd = &console.Command{
+	Category: "local",
+	Name:     "docker:up",
+	Aliases:  []*console.Alias{{Name: "docker:up"}},
+	Usage:    "Start the Docker Compose services of the project",
+	Flags: []console.Flag{
+		dirFlag,
+		&console.StringSliceFlag{Name: "profile", Usage: "Docker Compose profile to enable (COMPOSE_PROFILES is used by default)"},
+		&console.BoolFlag{Name: "no-wait", Usage: "Do not wait for the services to be ready"},
+		&console.DurationFlag{Name: "timeout", DefaultValue: time.Minute, Usage: "Maximum time to wait for the services to be ready"},
+	},
+	Action: func(c *console.Context) error {
+		projectDir, err := getProjectDir(c.String("dir"))
+		if err != nil {
+			return err
+		}
+		ui := terminal.SymfonyStyle(terminal.Stdout, terminal.Stdin)
+		if err := startDockerServices(projectDir, c.StringSlice("profile"), !c.Bool("no-wait"), c.Duration("timeout")); err != nil {
+			return err
+		}
+		ui.Success("Docker Compose services started successfully")
+		return nil
+	},
+}
+
+// dockerServicesFile flags the Docker Compose services started along the
+// local web server so that they can be stopped with it
+func dockerServicesFile(projectDir string) string {
+	return filepath.Join(util.GetHomeDir(), "var", pid.New(projectDir, nil).Name()+".docker")
+}
+
+func startDockerServices(projectDir string, profiles []string, wait bool, timeout time.Duration) error {
+	env, err := envs.NewLocal(projectDir, terminal.IsDebug())
+	if err != nil {
+		return err
+	}
+	cmd, err := env.ComposeCommand(append(profileArgs(profiles), "up", "--detach")...)
+	if err != nil {
+		return err
+	}
+	terminal.Printfln("Starting Docker Compose services in <comment>%s</>", env.ComposeDir())
+	if err := cmd.Run(); err != nil {
+		return errors.Wrap(err, "unable to start the Docker Compose services")
+	}
+	if !wait {
+		return nil
+	}
+	terminal.Println("Waiting for the Docker Compose services to be ready")
+	return env.WaitForDockerServices(timeout)
+}
+
+// stopDockerServices stops the Docker Compose services started by server:start --with-docker
+func stopDockerServices(projectDir string) error {
+	file := dockerServicesFile(projectDir)
+	if _, err := os.Stat(file); err != nil {
+		return nil
+	}
+	env, err := envs.NewLocal(projectDir, terminal.IsDebug())
+	if err != nil {
+		return err
+	}
+	cmd, err := env.ComposeCommand("stop")
+	if err != nil {
+		return err
+	}
+	terminal.Printfln("Stopping Docker Compose services in <comment>%s</>", env.ComposeDir())
+	if err := cmd.Run(); err != nil {
+		return errors.Wrap(err, "unable to stop the Docker Compose services")
+	}
+	return errors.WithStack(os.Remove(file))
+}
+
+func markDockerServices(projectDir string) error {
+	file := dockerServicesFile(projectDir)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(ioutil.WriteFile(file, []byte(projectDir), 0644))
+}
+
+func profileArgs(profiles []string) []string {
+	var args []string
+	for _, profile := range profiles {
+		args = append(args, "--profile", profile)
+	}
+	return args
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/xid"
+	"github.com/symfony-cli/console"
+	"github.com/symfony-cli/symfony-cli/envs"
+	"github.com/symfony-cli/symfony-cli/local/php"
+	"github.com/symfony-cli/symfony-cli/local/platformsh"
+	"github.com/symfony-cli/symfony-cli/util"
+	"github.com/symfony-cli/terminal"
+)
+
+var localHooksRunCmd = &console.Command{
+	Category: "local",
+	Name:     "hooks:run",
+	Aliases:  []*console.Alias{{Name: "hooks:run"}},
+	Usage:    "Run the Platform.sh build, deploy, and post_deploy hooks locally",
+	Description: `Each hook is run as a single shell script, like on Platform.sh: it only fails
+when its last command fails. Add "set -e" at the top of a hook to make it stop
+on the first failing command.`,
+	Flags: []console.Flag{
+		dirFlag,
+	},
+	Args: []*console.Arg{
+		{Name: "hooks", Optional: true, Slice: true, Description: "The hooks to run (build, deploy, or post_deploy), all of them by default"},
+	},
+	Action: func(c *console.Context) error {
+		projectDir, err := getProjectDir(c.String("dir"))
+		if err != nil {
+			return err
+		}
+
+		hooks := c.Args().Tail()
+		if hook := c.Args().Get("hooks"); hook != "" {
+			hooks = append([]string{hook}, hooks...)
+		}
+		if len(hooks) == 0 {
+			hooks = platformsh.HookNames
+		}
+
+		if err := runPlatformshHooks(projectDir, hooks); err != nil {
+			return err
+		}
+
+		terminal.SymfonyStyle(terminal.Stdout, terminal.Stdin).Success(fmt.Sprintf("Successfully ran: %s", strings.Join(hooks, ", ")))
+		return nil
+	},
+}
+
+// runPlatformshHooks runs the given hooks of the Platform.sh application
+// of the project directory, it stops on the first failing hook
+func runPlatformshHooks(projectDir string, hooks []string) error {
+	if runtime.GOOS == "windows" {
+		return errors.New("Running Platform.sh hooks is not supported on Windows")
+	}
+
+	app := platformsh.GuessSelectedAppByDirectory(projectDir, platformsh.FindLocalApplications(projectDir))
+	if app == nil {
+		return errors.New("Unable to find a Platform.sh application for this project")
+	}
+
+	scripts := make([]string, len(hooks))
+	for i, hook := range hooks {
+		script, err := app.Hooks.Get(hook)
+		if err != nil {
+			return err
+		}
+		scripts[i] = script
+	}
+
+	env, err := envs.GetEnv(projectDir, terminal.IsDebug())
+	if err != nil {
+		return err
+	}
+	binDir, err := createPHPWrappers()
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(filepath.Dir(binDir))
+
+	environ := append(os.Environ(), envs.AsSlice(env)...)
+	environ = append(environ, fmt.Sprintf("PATH=%s%c%s", binDir, filepath.ListSeparator, os.Getenv("PATH")))
+
+	for i, hook := range hooks {
+		if strings.TrimSpace(scripts[i]) == "" {
+			terminal.Printfln("Skipping the <info>%s</> hook as it is empty", hook)
+			continue
+		}
+
+		terminal.Printfln("Running the <info>%s</> hook", hook)
+		// like on Platform.sh, a hook is run as a single script and only fails
+		// when its last command fails, unless it starts with "set -e"
+		cmd := exec.Command("sh", "-c", scripts[i])
+		cmd.Dir = app.LocalRootDir
+		cmd.Env = environ
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return errors.Wrapf(err, "the %s hook failed", hook)
+		}
+	}
+
+	return nil
+}
+
+// createPHPWrappers creates a directory of scripts forwarding the PHP and
+// Composer commands to their "symfony" wrappers to use the project PHP version
+func createPHPWrappers() (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	binDir := filepath.Join(util.GetHomeDir(), "tmp", xid.New().String(), "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	for _, name := range append(php.GetBinaryNames(), "composer") {
+		script := fmt.Sprintf("#!/bin/sh\nexec \"%s\" %s \"$@\"\n", executable, name)
+		if err := ioutil.WriteFile(filepath.Join(binDir, name), []byte(script), 0755); err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+
+	return binDir, nil
+}
@@ -32,12 +32,12 @@ import (
 	"regexp"
 	"strings"
 
-	compose "github.com/compose-spec/compose-go/cli"
 	"github.com/hashicorp/go-version"
 	"github.com/pkg/errors"
 	"github.com/symfony-cli/console"
 	"github.com/symfony-cli/phpstore"
 	"github.com/symfony-cli/symfony-cli/book"
+	"github.com/symfony-cli/symfony-cli/envs"
 	"github.com/symfony-cli/symfony-cli/git"
 	"github.com/symfony-cli/symfony-cli/local/php"
 	"github.com/symfony-cli/symfony-cli/local/platformsh"
@@ -270,11 +270,7 @@ func parseCLIServices(services []string) ([]*CloudService, error) {
 func parseDockerComposeServices(dir string) []*CloudService {
 	var cloudServices []*CloudService
 
-	options, err := compose.NewProjectOptions(nil, compose.WithWorkingDirectory(dir), compose.WithDefaultConfigPath, compose.WithConfigFileEnv, compose.WithEnv(os.Environ()))
-	if err != nil {
-		return nil
-	}
-	project, err := compose.ProjectFromOptions(options)
+	project, err := envs.LoadComposeProject(dir)
 	if err != nil {
 		return nil
 	}
@@ -34,8 +34,10 @@ import (
 	"github.com/symfony-cli/cert"
 	"github.com/symfony-cli/console"
 	"github.com/symfony-cli/symfony-cli/humanlog"
+	"github.com/symfony-cli/symfony-cli/local/logrotate"
 	"github.com/symfony-cli/symfony-cli/local/pid"
 	"github.com/symfony-cli/symfony-cli/local/proxy"
+	"github.com/symfony-cli/symfony-cli/local/supervisor"
 	"github.com/symfony-cli/symfony-cli/reexec"
 	"github.com/symfony-cli/symfony-cli/util"
 	"github.com/symfony-cli/terminal"
@@ -66,7 +68,7 @@ var localProxyStartCmd = &console.Command{
 			if err := os.MkdirAll(varDir, 0755); err != nil {
 				return errors.Wrap(err, "Could not create status file")
 			}
-			if err := reexec.Background(varDir); err != nil {
+			if err := supervisor.Background(varDir); err != nil {
 				if _, isExitCoder := err.(console.ExitCoder); isExitCoder {
 					return err
 				}
@@ -103,14 +105,13 @@ var localProxyStartCmd = &console.Command{
 		if terminal.IsVerbose() {
 			zerolog.SetGlobalLevel(zerolog.DebugLevel)
 		}
-		logFile := filepath.Join(homeDir, "log", "proxy.log")
-		if err := os.MkdirAll(filepath.Dir(logFile), 0755); err != nil {
-			return errors.WithStack(err)
+		rotationConfig, err := logrotate.LoadConfig(homeDir)
+		if err != nil {
+			return err
 		}
-		os.Remove(logFile)
-		f, err := os.Create(logFile)
+		f, err := logrotate.Open(filepath.Join(homeDir, "log", "proxy.log"), rotationConfig, true)
 		if err != nil {
-			return errors.WithStack(err)
+			return err
 		}
 		var lw io.Writer
 		lw = f
@@ -22,6 +22,7 @@ package commands
 import (
 	"github.com/symfony-cli/console"
 	"github.com/symfony-cli/symfony-cli/local/pid"
+	"github.com/symfony-cli/symfony-cli/local/supervisor"
 	"github.com/symfony-cli/terminal"
 )
 
@@ -43,6 +44,9 @@ var localProxyStatusCmd = &console.Command{
 		}
 
 		terminal.Printfln("    Listening on <href=%s://127.0.0.1:%d>%s://127.0.0.1:%d</>", pidFile.Scheme, pidFile.Port, pidFile.Scheme, pidFile.Port)
+		if p := supervisor.Find(pidFile.Pid); p != nil {
+			terminal.Printfln("    Supervised as PID <info>%d</> (<comment>%d</> restart(s))", p.Pid, p.Restarts)
+		}
 
 		terminal.Println()
 		terminal.Println("<info>Configured Web Servers</>")
@@ -22,6 +22,7 @@ package commands
 import (
 	"github.com/symfony-cli/console"
 	"github.com/symfony-cli/symfony-cli/local/pid"
+	"github.com/symfony-cli/symfony-cli/local/supervisor"
 	"github.com/symfony-cli/terminal"
 )
 
@@ -37,6 +38,9 @@ var localProxyStopCmd = &console.Command{
 			ui.Success("The proxy server is not running")
 			return nil
 		}
+		if err := supervisor.Release(p.Pid); err != nil {
+			return err
+		}
 		if err := p.Stop(); err != nil {
 			return err
 		}
@@ -42,6 +42,16 @@ var localRunCmd = &console.Command{
 			Name:  "watch",
 			Usage: "Restart command when some change happens on this file or in this directory (recursively)",
 		},
+		&console.StringSliceFlag{
+			Name:  "watch-include",
+			Usage: "Only restart command on changes to files matching this glob pattern",
+		},
+		&console.StringSliceFlag{
+			Name:  "watch-exclude",
+			Usage: "Do not restart command on changes to files matching this glob pattern",
+		},
+		&console.BoolFlag{Name: "watch-gitignore", Usage: "Do not restart command on changes to files ignored by the project .gitignore"},
+		&console.DurationFlag{Name: "watch-debounce", Usage: "Time to wait for changes to settle down before restarting command (500ms by default)"},
 	},
 	FlagParsing: console.FlagParsingSkippedAfterFirstArg,
 	Args: []*console.Arg{
@@ -49,10 +59,7 @@ var localRunCmd = &console.Command{
 		{Name: "args", Optional: true, Slice: true},
 	},
 	Action: func(c *console.Context) error {
-		directories := make([]string, 0, len(c.StringSlice("watch")))
-		for _, directory := range c.StringSlice("watch") {
-			directories = append(directories, strings.Split(directory, ",")...)
-		}
+		directories := splitFlagValues(c.StringSlice("watch"))
 		projectDir, err := getProjectDir(c.String("dir"))
 		if err != nil {
 			return err
@@ -72,6 +79,12 @@ var localRunCmd = &console.Command{
 		if err != nil {
 			return err
 		}
+		runner.WatchOptions = &local.WatchOptions{
+			Include:   splitFlagValues(c.StringSlice("watch-include")),
+			Exclude:   splitFlagValues(c.StringSlice("watch-exclude")),
+			GitIgnore: c.Bool("watch-gitignore"),
+			Debounce:  c.Duration("watch-debounce"),
+		}
 
 		runner.BuildCmdHook = func(cmd *exec.Cmd) error {
 			env, err := envs.GetEnv(pidFile.Dir, terminal.IsDebug())
@@ -96,3 +109,12 @@ var localRunCmd = &console.Command{
 		return nil
 	},
 }
+
+// splitFlagValues supports both repeated flags and comma separated values
+func splitFlagValues(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		result = append(result, strings.Split(value, ",")...)
+	}
+	return result
+}
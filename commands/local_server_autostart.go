@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package commands
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/pkg/errors"
+	"github.com/symfony-cli/console"
+	"github.com/symfony-cli/symfony-cli/local/projects"
+	"github.com/symfony-cli/symfony-cli/util"
+	"github.com/symfony-cli/terminal"
+)
+
+var localServerAutostartCmd = &console.Command{
+	Category: "local",
+	Name:     "server:autostart",
+	Aliases:  []*console.Alias{{Name: "server:autostart"}},
+	Usage:    "Enable or disable starting the local web server of the project on login",
+	Flags: []console.Flag{
+		dirFlag,
+	},
+	Args: []*console.Arg{
+		{Name: "action", Description: "enable or disable"},
+	},
+	Action: func(c *console.Context) error {
+		projectDir, err := getProjectDir(c.String("dir"))
+		if err != nil {
+			return err
+		}
+
+		autostart, err := projects.LoadAutostart(util.GetHomeDir())
+		if err != nil {
+			return err
+		}
+
+		ui := terminal.SymfonyStyle(terminal.Stdout, terminal.Stdin)
+		switch action := c.Args().Get("action"); action {
+		case "enable":
+			autostart.Enable(projectDir)
+			if err := autostart.Save(); err != nil {
+				return err
+			}
+			ui.Success(fmt.Sprintf("The local web server of %s will be started by \"%s %s\"", projectDir, c.App.HelpName, localBootCmd.FullName()))
+			if runtime.GOOS == "linux" {
+				return installSystemdUnit()
+			}
+		case "disable":
+			if !autostart.Disable(projectDir) {
+				ui.Success(fmt.Sprintf("The local web server of %s is not started automatically", projectDir))
+				return nil
+			}
+			if err := autostart.Save(); err != nil {
+				return err
+			}
+			ui.Success(fmt.Sprintf("The local web server of %s will not be started automatically anymore", projectDir))
+		default:
+			return console.IncorrectUsageError{ParentError: errors.Errorf(`Unknown action "%s", must be "enable" or "disable"`, action)}
+		}
+
+		return nil
+	},
+}
@@ -20,9 +20,26 @@
 package commands
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
 	"github.com/symfony-cli/console"
+	"github.com/symfony-cli/symfony-cli/envs"
+	"github.com/symfony-cli/symfony-cli/humanlog"
 	"github.com/symfony-cli/symfony-cli/local/logs"
+	"github.com/symfony-cli/symfony-cli/local/php"
 	"github.com/symfony-cli/symfony-cli/local/pid"
+	"github.com/symfony-cli/symfony-cli/local/project"
+	"github.com/symfony-cli/symfony-cli/util"
 	"github.com/symfony-cli/terminal"
 )
 
@@ -36,6 +53,11 @@ var localServerLogCmd = &console.Command{
 		&console.BoolFlag{Name: "no-follow", Aliases: []string{"no-tail"}, Usage: "Do no tail the logs"},
 		&console.Int64Flag{Name: "lines", Aliases: []string{"n"}, DefaultValue: 0, Usage: "Number of lines to display at start"},
 		&console.BoolFlag{Name: "no-humanize", Usage: "Do not format JSON logs"},
+		&console.StringFlag{Name: "format", DefaultValue: "text", Usage: "Output format (text or json)"},
+		&console.BoolFlag{Name: "full-traces", Usage: "Do not collapse stack traces"},
+		&console.StringFlag{Name: "timezone", Usage: "Display times in this time zone (UTC, Europe/Paris, ...), the local one by default"},
+		&console.StringFlag{Name: "log-timezone", Usage: "Time zone of the times logged without one, the local one by default"},
+		&console.StringFlag{Name: "time-format", DefaultValue: "stamp", Usage: "Display times as a date (stamp), an ISO 8601 timestamp (iso), or a duration (relative)"},
 		&console.StringSliceFlag{
 			Name:  "file",
 			Usage: "Use this file for application logs",
@@ -43,6 +65,12 @@ var localServerLogCmd = &console.Command{
 		&console.BoolFlag{Name: "no-app-logs", Usage: "Do not display the application logs"},
 		&console.BoolFlag{Name: "no-worker-logs", Usage: "Do not display the worker logs"},
 		&console.BoolFlag{Name: "no-server-logs", Usage: "Do not display web server/PHP logs"},
+		&console.StringFlag{Name: "remote", Usage: "Also stream the logs of this Platform.sh environment"},
+		&console.StringSliceFlag{Name: "source", Usage: "Only display logs from these sources (PHP-FPM, Application, a worker name, ...)"},
+		&console.StringFlag{Name: "level", Usage: "Only display logs with at least this level (debug, info, notice, warning, error, critical, ...)"},
+		&console.StringFlag{Name: "grep", Usage: "Only display logs matching this regular expression"},
+		&console.StringFlag{Name: "since", Usage: "Only display logs since this time (10m, 2h, 2006-01-02 15:04:05)"},
+		&console.StringFlag{Name: "status", Usage: "Only display access logs with this HTTP status (404, 5xx, 400-499)"},
 	},
 	Action: func(c *console.Context) error {
 		projectDir, err := getProjectDir(c.String("dir"))
@@ -50,20 +78,202 @@ var localServerLogCmd = &console.Command{
 			return err
 		}
 
+		_, fileConfig, err := project.NewConfigFromContext(c, projectDir)
+		if err != nil {
+			return err
+		}
+
+		if err := registerLogFormats(fileConfig); err != nil {
+			return err
+		}
+
+		filter, err := logFilterFromFlags(c)
+		if err != nil {
+			return err
+		}
+
+		format := c.String("format")
+		if format != "text" && format != "json" {
+			return errors.Errorf("unsupported format \"%s\", use text or json", format)
+		}
+
+		timeFormat, err := humanlog.ParseTimeFormat(c.String("time-format"))
+		if err != nil {
+			return err
+		}
+		location, err := loadLocation(c.String("timezone"))
+		if err != nil {
+			return err
+		}
+		zonelessLocation, err := loadLocation(c.String("log-timezone"))
+		if err != nil {
+			return err
+		}
+
 		tailer := logs.Tailer{
-			Follow:       !c.Bool("no-follow"),
-			LinesNb:      c.Int64("lines"),
-			AppLogs:      c.StringSlice("file"),
-			NoHumanize:   c.Bool("no-humanize"),
-			NoAppLogs:    c.Bool("no-app-logs"),
-			NoWorkerLogs: c.Bool("no-worker-logs"),
-			NoServerLogs: c.Bool("no-server-logs"),
+			Follow:           !c.Bool("no-follow"),
+			LinesNb:          c.Int64("lines"),
+			AppLogs:          c.StringSlice("file"),
+			AppLogPatterns:   applicationLogPatterns(fileConfig),
+			AppEnv:           envs.AppEnv(projectDir),
+			NoHumanize:       c.Bool("no-humanize"),
+			NoAppLogs:        c.Bool("no-app-logs"),
+			NoWorkerLogs:     c.Bool("no-worker-logs"),
+			NoServerLogs:     c.Bool("no-server-logs"),
+			Filter:           filter,
+			JSON:             format == "json",
+			FullTraces:       c.Bool("full-traces"),
+			Location:         location,
+			ZonelessLocation: zonelessLocation,
+			TimeFormat:       timeFormat,
 		}
 
 		if err := tailer.Watch(pid.New(projectDir, nil)); err != nil {
 			return err
 		}
 
+		if env := c.String("remote"); env != "" {
+			if err := watchRemoteLogs(&tailer, projectDir, env, c.Int64("lines")); err != nil {
+				return err
+			}
+		}
+
+		if tailer.JSON {
+			// JSON records are written raw on stdout to be consumed by tools
+			return tailer.Tail(os.Stdout)
+		}
 		return tailer.Tail(terminal.Stderr)
 	},
 }
+
+// remoteLogTypes are the Platform.sh log types streamed by server:log --remote
+var remoteLogTypes = []string{"app", "access", "error"}
+
+// watchRemoteLogs streams the logs of a Platform.sh environment via the
+// Platform.sh CLI into the tailer
+func watchRemoteLogs(tailer *logs.Tailer, projectDir, env string, lines int64) error {
+	psh, err := GetPSH()
+	if err != nil {
+		return err
+	}
+	if !util.InCloud() {
+		home, err := homedir.Dir()
+		if err != nil {
+			return err
+		}
+		if err := php.InstallPlatformPhar(home); err != nil {
+			return console.Exit(err.Error(), 1)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, logType := range remoteLogTypes {
+		r, w := io.Pipe()
+		args := []string{"log", logType, "--tail", "--environment=" + env, "--yes"}
+		if lines > 0 {
+			args = append(args, fmt.Sprintf("--lines=%d", lines))
+		}
+		e := psh.executor(args)
+		e.Dir = projectDir
+		e.Stdin = strings.NewReader("")
+		e.Stdout = w
+		e.Stderr = w
+		name := env + "/" + logType
+		terminal.Logger.Debug().Str("cmd", strings.Join(e.Args, " ")).Msg("Streaming Platform.sh logs")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ret := e.Execute(false); ret != 0 {
+				terminal.Eprintfln("<warning>WARNING</> %s logs stream exited with status %d", name, ret)
+			}
+			w.Close()
+		}()
+		tailer.WatchStream(name, r)
+	}
+
+	// the executor intercepts signals to forward them to the Platform.sh
+	// CLI, so stop tailing once the remote commands are done
+	go func() {
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+		<-sigs
+		wg.Wait()
+		tailer.Stop()
+	}()
+	return nil
+}
+
+// applicationLogPatterns returns the application log files configured in .symfony.local.yaml
+func applicationLogPatterns(fileConfig *project.FileConfig) []string {
+	if fileConfig == nil || fileConfig.Logs == nil {
+		return nil
+	}
+	return fileConfig.Logs.Files
+}
+
+// registerLogFormats makes the custom log formats of .symfony.local.yaml
+// available when humanizing logs
+func registerLogFormats(fileConfig *project.FileConfig) error {
+	if fileConfig == nil || fileConfig.Logs == nil {
+		return nil
+	}
+	parsers, err := fileConfig.Logs.Parsers()
+	if err != nil {
+		return err
+	}
+	for _, p := range parsers {
+		humanlog.RegisterParser(p)
+	}
+	return nil
+}
+
+func logFilterFromFlags(c *console.Context) (*logs.Filter, error) {
+	filter := &logs.Filter{}
+	for _, source := range c.StringSlice("source") {
+		for _, s := range strings.Split(source, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				filter.Sources = append(filter.Sources, s)
+			}
+		}
+	}
+	if level := c.String("level"); level != "" {
+		if humanlog.LevelSeverity(level) == -1 {
+			return nil, errors.Errorf("unknown log level \"%s\"", level)
+		}
+		filter.MinLevel = level
+	}
+	if grep := c.String("grep"); grep != "" {
+		re, err := regexp.Compile(grep)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid --grep regular expression")
+		}
+		filter.Pattern = re
+	}
+	if since := c.String("since"); since != "" {
+		t, err := logs.ParseSince(since)
+		if err != nil {
+			return nil, err
+		}
+		filter.Since = t
+	}
+	if status := c.String("status"); status != "" {
+		min, max, err := logs.ParseStatusRange(status)
+		if err != nil {
+			return nil, err
+		}
+		filter.StatusMin, filter.StatusMax = min, max
+	}
+	return filter, nil
+}
+
+// loadLocation returns nil (the local time zone) when tz is empty
+func loadLocation(tz string) (*time.Location, error) {
+	if tz == "" {
+		return nil, nil
+	}
+	location, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unknown time zone \"%s\"", tz)
+	}
+	return location, nil
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package commands
+
+import (
+	"os/exec"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/symfony-cli/symfony-cli/envs"
+	"github.com/symfony-cli/symfony-cli/local"
+	"github.com/symfony-cli/symfony-cli/local/cron"
+	"github.com/symfony-cli/symfony-cli/local/pid"
+	"github.com/symfony-cli/symfony-cli/local/platformsh"
+	"github.com/symfony-cli/symfony-cli/local/project"
+	"github.com/symfony-cli/terminal"
+)
+
+// scheduledTask is a command run periodically by the local web server
+type scheduledTask struct {
+	name     string
+	schedule *cron.Schedule
+	args     []string
+}
+
+// collectScheduledTasks returns the Platform.sh crons of the current
+// application and the schedules defined in ".symfony.local.yaml"
+func collectScheduledTasks(projectDir string, fileConfig *project.FileConfig) []*scheduledTask {
+	var tasks []*scheduledTask
+
+	if app := platformsh.GuessSelectedAppByDirectory(projectDir, platformsh.FindLocalApplications(projectDir)); app != nil {
+		for name, c := range app.Crons {
+			schedule, err := cron.Parse(c.Spec)
+			if err != nil {
+				terminal.Eprintfln("<warning>WARNING</> Skipping the \"%s\" cron: %s", name, err)
+				continue
+			}
+			if c.Command() == "" {
+				continue
+			}
+			tasks = append(tasks, &scheduledTask{name: name, schedule: schedule, args: shellCommand(c.Command())})
+		}
+	}
+
+	if fileConfig != nil {
+		for name, s := range fileConfig.Schedules {
+			// specs have already been validated when loading the config
+			schedule, _ := cron.Parse(s.Spec)
+			tasks = append(tasks, &scheduledTask{name: name, schedule: schedule, args: s.Cmd})
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].name < tasks[j].name
+	})
+
+	return tasks
+}
+
+func shellCommand(command string) []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/C", command}
+	}
+	return []string{"sh", "-c", command}
+}
+
+// runScheduledTasks runs each task at the time defined by its schedule until
+// the server stops; a run is skipped if the previous one is still running
+func runScheduledTasks(projectDir string, tasks []*scheduledTask) {
+	for _, task := range tasks {
+		pidFile := pid.New(projectDir, task.args)
+		pidFile.CustomName = "cron:" + task.name
+		pidFile.Scheduled = true
+		if err := pidFile.Write(0, 0, ""); err != nil {
+			terminal.Logger.Error().Msgf("Unable to schedule \"%s\": %s", task.name, err)
+			continue
+		}
+
+		terminal.Logger.Debug().Msgf("Scheduling \"%s\" (%s)", task.name, task.args)
+		go func(task *scheduledTask) {
+			running := make(chan bool, 1)
+			for {
+				next := task.schedule.Next(time.Now())
+				if next.IsZero() {
+					return
+				}
+				time.Sleep(time.Until(next))
+
+				select {
+				case running <- true:
+				default:
+					terminal.Logger.Warn().Msgf("Skipping \"%s\" as the previous run is still running", task.name)
+					continue
+				}
+				go func() {
+					defer func() { <-running }()
+					if err := runScheduledTask(projectDir, task); err != nil {
+						terminal.Logger.Error().Msgf("Scheduled task \"%s\" failed: %s", task.name, err)
+					}
+				}()
+			}
+		}(task)
+	}
+}
+
+func runScheduledTask(projectDir string, task *scheduledTask) error {
+	pidFile := pid.New(projectDir, task.args)
+	pidFile.CustomName = "cron:" + task.name
+	pidFile.Scheduled = true
+	// the PID file is kept between runs (with no PID) so that the logs of
+	// short-lived commands are followed as well; it is removed when the
+	// server stops
+	if err := pidFile.Write(0, 0, ""); err != nil {
+		return err
+	}
+	defer pidFile.Write(0, 0, "")
+
+	runner, err := local.NewRunner(pidFile, local.RunnerModeLoopAttached)
+	if err != nil {
+		return err
+	}
+	runner.RestartPolicy = local.RestartNever
+	runner.AppendLogs = true
+	runner.BuildCmdHook = func(cmd *exec.Cmd) error {
+		env, err := envs.GetEnv(projectDir, terminal.IsDebug())
+		if err != nil {
+			return err
+		}
+		cmd.Env = append(cmd.Env, envs.AsSlice(env)...)
+		return nil
+	}
+
+	return runner.Run()
+}
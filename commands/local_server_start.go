@@ -26,10 +26,13 @@ import (
 	"fmt"
 	"net/http"
 	"os"
-	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -38,11 +41,12 @@ import (
 	"github.com/symfony-cli/console"
 	"github.com/symfony-cli/symfony-cli/envs"
 	"github.com/symfony-cli/symfony-cli/humanlog"
-	"github.com/symfony-cli/symfony-cli/local"
+	"github.com/symfony-cli/symfony-cli/inotify"
 	"github.com/symfony-cli/symfony-cli/local/logs"
 	"github.com/symfony-cli/symfony-cli/local/pid"
 	"github.com/symfony-cli/symfony-cli/local/project"
 	"github.com/symfony-cli/symfony-cli/local/proxy"
+	"github.com/symfony-cli/symfony-cli/local/supervisor"
 	"github.com/symfony-cli/symfony-cli/reexec"
 	"github.com/symfony-cli/symfony-cli/util"
 	"github.com/symfony-cli/terminal"
@@ -68,6 +72,9 @@ var localServerStartCmd = &console.Command{
 		&console.StringFlag{Name: "p12", Usage: "Name of the file containing the TLS certificate to use in p12 format"},
 		&console.BoolFlag{Name: "no-tls", Usage: "Use HTTP instead of HTTPS"},
 		&console.BoolFlag{Name: "use-gzip", Usage: "Use GZIP"},
+		&console.BoolFlag{Name: "with-docker", Usage: "Start the Docker Compose services before starting the server (stopped by server:stop)"},
+		&console.BoolFlag{Name: "with-hooks", Usage: "Run the Platform.sh deploy hook before starting the server"},
+		&console.BoolFlag{Name: "no-crons", Usage: "Do not run the Platform.sh crons and the schedules defined in .symfony.local.yaml"},
 	},
 	Action: func(c *console.Context) error {
 		ui := terminal.SymfonyStyle(terminal.Stdout, terminal.Stdin)
@@ -85,6 +92,21 @@ var localServerStartCmd = &console.Command{
 			return err
 		}
 
+		if c.Bool("with-docker") && !reexec.IsChild() {
+			if err := startDockerServices(projectDir, nil, true, time.Minute); err != nil {
+				return err
+			}
+			if err := markDockerServices(projectDir); err != nil {
+				return err
+			}
+		}
+
+		if c.Bool("with-hooks") && !reexec.IsChild() {
+			if err := runPlatformshHooks(projectDir, []string{"deploy"}); err != nil {
+				return err
+			}
+		}
+
 		homeDir := util.GetHomeDir()
 
 		shutdownCh := make(chan bool, 1)
@@ -101,7 +123,7 @@ var localServerStartCmd = &console.Command{
 			if err := os.MkdirAll(varDir, 0755); err != nil {
 				return errors.Wrap(err, "Could not create status file")
 			}
-			if err := reexec.Background(varDir); err != nil {
+			if err := supervisor.Background(varDir); err != nil {
 				if _, isExitCoder := err.(console.ExitCoder); isExitCoder {
 					return err
 				}
@@ -182,10 +204,16 @@ var localServerStartCmd = &console.Command{
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
+		if err := registerLogFormats(fileConfig); err != nil {
+			return err
+		}
+
 		tailer := logs.Tailer{
-			Follow:     true,
-			NoHumanize: c.Bool("no-humanize"),
-			LinesNb:    10, // needed to catch early logs
+			Follow:         true,
+			NoHumanize:     c.Bool("no-humanize"),
+			LinesNb:        10, // needed to catch early logs
+			AppLogPatterns: applicationLogPatterns(fileConfig),
+			AppEnv:         envs.AppEnv(projectDir),
 		}
 
 		errChan := make(chan error, 1)
@@ -292,44 +320,24 @@ var localServerStartCmd = &console.Command{
 			go tailer.Tail(terminal.Stderr)
 		}
 
-		if fileConfig != nil {
-			reexec.NotifyForeground("workers")
-			for name, worker := range fileConfig.Workers {
-				pidFile := pid.New(projectDir, worker.Cmd)
-				if pidFile.IsRunning() {
-					terminal.Eprintfln("<warning>WARNING</> Unable to start worker \"%s\": it is already running for this project as PID %d", name, pidFile.Pid)
-					continue
-				}
-				pidFile.Watched = worker.Watch
-
-				// we run each worker in its own goroutine for several reasons:
-				// * to get things up and running faster
-				// * to allow all commands to run when foreground is forced
-				go func(name string, pidFile *pid.PidFile) {
-					runner, err := local.NewRunner(pidFile, local.RunnerModeLoopAttached)
-					if err != nil {
-						terminal.Eprintfln("<warning>WARNING</> Unable to start worker \"%s\": %s", name, err)
-						return
-					}
-
-					env, err := envs.GetEnv(pidFile.Dir, terminal.IsDebug())
-					if err != nil {
-						errChan <- errors.WithStack(err)
-						return
-					}
-
-					runner.BuildCmdHook = func(cmd *exec.Cmd) error {
-						cmd.Env = append(cmd.Env, envs.AsSlice(env)...)
-
-						return nil
-					}
-
-					ui.Success(fmt.Sprintf("Started worker \"%s\"", name))
-					if err := runner.Run(); err != nil {
-						terminal.Eprintfln("<warning>WARNING</> Worker \"%s\" exited with an error: %s", name, err)
-					}
-				}(name, pidFile)
-			}
+		reexec.NotifyForeground("workers")
+		if fileConfig == nil {
+			fileConfig = &project.FileConfig{Workers: make(map[string]*project.Worker)}
+		}
+		names, err := fileConfig.WorkersStartOrder()
+		if err != nil {
+			return err
+		}
+		workers := newWorkersManager(projectDir, fileConfig.Workers, errChan)
+		workers.start(names)
+		if err := workers.watchRequests(); err != nil {
+			return err
+		}
+		if err := watchLocalConfigFile(projectDir, fileConfig, proxyConfig, workers); err != nil {
+			return err
+		}
+		if !c.Bool("no-crons") {
+			runScheduledTasks(projectDir, collectScheduledTasks(projectDir, fileConfig))
 		}
 
 		reexec.NotifyForeground(reexec.UP)
@@ -346,6 +354,11 @@ var localServerStartCmd = &console.Command{
 			if err := cleanupWebServerFiles(projectDir, pidFile); err != nil {
 				return err
 			}
+			if !reexec.IsChild() {
+				if err := stopDockerServices(projectDir); err != nil {
+					return err
+				}
+			}
 			terminal.Eprintln("")
 			ui.Success("Stopped all processes successfully")
 		}
@@ -354,11 +367,13 @@ var localServerStartCmd = &console.Command{
 }
 
 func cleanupWebServerFiles(projectDir string, pidFile *pid.PidFile) error {
-	pids := pid.AllWorkers(projectDir)
+	pids := pid.AllWorkersAndScheduledTasks(projectDir)
 	var g errgroup.Group
 	for _, p := range pids {
 		if p.IsRunning() {
 			g.Go(p.Stop)
+		} else {
+			p.Remove()
 		}
 	}
 	if err := g.Wait(); err != nil {
@@ -367,5 +382,83 @@ func cleanupWebServerFiles(projectDir string, pidFile *pid.PidFile) error {
 	if err := pidFile.Remove(); err != nil {
 		return err
 	}
+	if err := os.RemoveAll(workersRequestsDir(projectDir)); err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// watchLocalConfigFile applies the changes made to .symfony.local.yaml
+// while the server is running (workers and proxy domains)
+func watchLocalConfigFile(projectDir string, fileConfig *project.FileConfig, proxyConfig *proxy.Config, workers *workersManager) error {
+	configFile := filepath.Join(projectDir, ".symfony.local.yaml")
+	// watch the directory as editors often replace the file instead of writing into it
+	c := make(chan inotify.EventInfo, 10)
+	if err := inotify.Watch(projectDir, c, inotify.Create, inotify.Write, inotify.Remove, inotify.Rename); err != nil {
+		return errors.Wrap(err, "unable to watch the .symfony.local.yaml file")
+	}
+
+	// fileConfig.HTTP has been altered by the command flags, so we need a
+	// pristine version to compare with
+	if pristine, err := project.NewFileConfig(projectDir); err == nil {
+		fileConfig = pristine
+	}
+
+	go func() {
+		// wait a bit for changes to settle down as editors might generate
+		// several events for one save
+		timer := time.NewTimer(time.Hour)
+		timer.Stop()
+		for {
+			select {
+			case e := <-c:
+				if e.Path() == configFile {
+					timer.Reset(200 * time.Millisecond)
+				}
+				continue
+			case <-timer.C:
+			}
+
+			newConfig, err := project.NewFileConfig(projectDir)
+			if err != nil {
+				terminal.Eprintfln("<warning>WARNING</> Unable to reload \".symfony.local.yaml\": %s", err)
+				continue
+			}
+			terminal.Eprintln("Reloading \".symfony.local.yaml\"")
+
+			if !reflect.DeepEqual(fileConfig.HTTP, newConfig.HTTP) {
+				terminal.Eprintln("  HTTP configuration changed, restart the server to apply it")
+			}
+
+			oldDomains := append([]string{}, fileConfig.Proxy.Domains...)
+			sort.Strings(oldDomains)
+			newDomains := append([]string{}, newConfig.Proxy.Domains...)
+			sort.Strings(newDomains)
+			if strings.Join(oldDomains, ", ") != strings.Join(newDomains, ", ") {
+				terminal.Eprintfln("  Proxy domains: %s -> %s", strings.Join(oldDomains, ", "), strings.Join(newDomains, ", "))
+				if err := proxyConfig.ReplaceDirDomains(projectDir, newConfig.Proxy.Domains); err != nil {
+					terminal.Eprintfln("<warning>WARNING</> Unable to update the proxy domains: %s", err)
+				}
+			}
+
+			added, removed, changed := fileConfig.WorkersDiff(newConfig)
+			for _, name := range added {
+				terminal.Eprintfln("  <info>+</> worker \"%s\"", name)
+			}
+			for _, name := range removed {
+				terminal.Eprintfln("  <error>-</> worker \"%s\"", name)
+			}
+			for _, name := range changed {
+				terminal.Eprintfln("  <comment>~</> worker \"%s\"", name)
+			}
+			if err := workers.reload(newConfig, added, removed, changed); err != nil {
+				terminal.Eprintfln("<warning>WARNING</> Unable to reload workers: %s", err)
+				continue
+			}
+
+			fileConfig = newConfig
+		}
+	}()
+
 	return nil
 }
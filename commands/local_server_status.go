@@ -20,14 +20,21 @@
 package commands
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"os/signal"
 	"strings"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/symfony-cli/console"
 	"github.com/symfony-cli/phpstore"
 	"github.com/symfony-cli/symfony-cli/envs"
 	"github.com/symfony-cli/symfony-cli/local/pid"
+	"github.com/symfony-cli/symfony-cli/local/process"
 	"github.com/symfony-cli/symfony-cli/local/proxy"
+	"github.com/symfony-cli/symfony-cli/local/supervisor"
 	"github.com/symfony-cli/symfony-cli/util"
 	"github.com/symfony-cli/terminal"
 )
@@ -39,6 +46,8 @@ var localServerStatusCmd = &console.Command{
 	Usage:    "Get the local web server status",
 	Flags: []console.Flag{
 		dirFlag,
+		&console.BoolFlag{Name: "watch", Usage: "Refresh the status every few seconds"},
+		&console.BoolFlag{Name: "json", Usage: "Output the status as JSON"},
 	},
 	Action: func(c *console.Context) error {
 		projectDir, err := getProjectDir(c.String("dir"))
@@ -46,69 +55,227 @@ var localServerStatusCmd = &console.Command{
 			return err
 		}
 
-		return printWebServerStatus(projectDir)
+		if c.Bool("json") {
+			status, err := getWebServerStatus(projectDir)
+			if err != nil {
+				return err
+			}
+			b, err := json.MarshalIndent(status, "", "    ")
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			terminal.Stdout.Write(append(b, '\n'))
+			return nil
+		}
+
+		if !c.Bool("watch") {
+			return printWebServerStatus(projectDir)
+		}
+
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, os.Interrupt)
+		defer signal.Stop(sigChan)
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+		cursor := terminal.NewCursor(terminal.Stdout)
+		for {
+			cursor.MoveToPosition(1, 0).ClearOutput()
+			if err := printWebServerStatus(projectDir); err != nil {
+				return err
+			}
+			select {
+			case <-sigChan:
+				return nil
+			case <-ticker.C:
+			}
+		}
 	},
 }
 
-func printWebServerStatus(projectDir string) error {
+type webServerStatus struct {
+	Running     bool             `json:"running"`
+	URL         string           `json:"url,omitempty"`
+	PHP         string           `json:"php,omitempty"`
+	PHPSource   string           `json:"php_source,omitempty"`
+	PHPWarning  string           `json:"php_warning,omitempty"`
+	Domains     []string         `json:"domains,omitempty"`
+	Process     *processStatus   `json:"process,omitempty"`
+	Workers     []*processStatus `json:"workers"`
+	Environment string           `json:"environment"`
+}
+
+type processStatus struct {
+	Pid          int      `json:"pid"`
+	Command      string   `json:"command,omitempty"`
+	Name         string   `json:"name,omitempty"`
+	Watched      []string `json:"watched,omitempty"`
+	CPU          float64  `json:"cpu_percent"`
+	RSS          uint64   `json:"rss_bytes"`
+	Uptime       float64  `json:"uptime_seconds"`
+	Restarts     int      `json:"restarts"`
+	LastExitCode *int     `json:"last_exit_code,omitempty"`
+	Supervised   bool     `json:"supervised"`
+
+	usageErr error
+}
+
+func newProcessStatus(p *pid.PidFile) *processStatus {
+	status := &processStatus{
+		Pid:          p.Pid,
+		Command:      p.Command(),
+		Name:         p.CustomName,
+		Watched:      p.Watched,
+		Restarts:     p.Restarts,
+		LastExitCode: p.LastExitCode,
+	}
+	if usage, err := process.GetUsage(p.Pid); err != nil {
+		status.usageErr = err
+	} else {
+		status.CPU = usage.CPU
+		status.RSS = usage.RSS
+		status.Uptime = usage.Uptime.Seconds()
+	}
+	return status
+}
+
+// String returns a human readable version of the resource usage
+func (s *processStatus) String() string {
+	var parts []string
+	if s.usageErr == nil {
+		parts = append(parts,
+			fmt.Sprintf("CPU <info>%.1f%%</>", s.CPU),
+			fmt.Sprintf("memory <info>%s</>", formatBytes(s.RSS)),
+			fmt.Sprintf("up <info>%s</>", time.Duration(s.Uptime)*time.Second),
+		)
+	}
+	if s.Supervised {
+		parts = append(parts, "<info>supervised</>")
+	}
+	if s.Restarts > 0 {
+		parts = append(parts, fmt.Sprintf("<comment>%d</> restart(s)", s.Restarts))
+	}
+	if s.LastExitCode != nil {
+		parts = append(parts, fmt.Sprintf("last exit code <comment>%d</>", *s.LastExitCode))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+func getWebServerStatus(projectDir string) (*webServerStatus, error) {
+	status := &webServerStatus{Workers: []*processStatus{}}
+
 	pidFile := pid.New(projectDir, nil)
-	workers := pid.AllWorkers(projectDir)
+	if pidFile.IsRunning() {
+		status.Running = true
+		status.URL = fmt.Sprintf("%s://127.0.0.1:%d", pidFile.Scheme, pidFile.Port)
+		status.Process = newProcessStatus(pidFile)
+		if p := supervisor.Find(pidFile.Pid); p != nil {
+			status.Process.Supervised = true
+			status.Process.Restarts = p.Restarts
+		}
+		phpStore := phpstore.New(util.GetHomeDir(), true, nil)
+		if version, source, warning, err := phpStore.BestVersionForDir(projectDir); err == nil {
+			status.PHP = fmt.Sprintf("%s %s", version.ServerTypeName(), version.Version)
+			status.PHPSource = source
+			status.PHPWarning = warning
+		}
+		if proxyConf, err := proxy.Load(util.GetHomeDir()); err == nil {
+			for _, domain := range proxyConf.GetDomains(projectDir) {
+				status.Domains = append(status.Domains, fmt.Sprintf("%s://%s", pidFile.Scheme, domain))
+			}
+		}
+	}
+
+	for _, p := range pid.AllWorkers(projectDir) {
+		status.Workers = append(status.Workers, newProcessStatus(p))
+	}
+
+	data, err := envs.GetEnv(projectDir, terminal.IsDebug())
+	if err != nil {
+		return nil, err
+	}
+	env := envs.AsMap(data)
+	status.Environment = "None"
+	if env["SYMFONY_TUNNEL"] != "" && env["SYMFONY_TUNNEL_ENV"] != "" {
+		status.Environment = "Platform.sh"
+	}
+	if env["SYMFONY_DOCKER_ENV"] == "1" && env["SYMFONY_TUNNEL_ENV"] == "" {
+		status.Environment = "Docker"
+	}
+
+	return status, nil
+}
+
+func printWebServerStatus(projectDir string) error {
+	status, err := getWebServerStatus(projectDir)
+	if err != nil {
+		return err
+	}
 
 	// web server
 	terminal.Println("<info>Local Web Server</>")
-	if !pidFile.IsRunning() {
+	if !status.Running {
 		terminal.Println("    <error>Not Running</>")
 	} else {
-		terminal.Printfln("    Listening on <href=%s://127.0.0.1:%d>%s://127.0.0.1:%d</>", pidFile.Scheme, pidFile.Port, pidFile.Scheme, pidFile.Port)
-		homeDir := util.GetHomeDir()
-		phpStore := phpstore.New(homeDir, true, nil)
-		version, source, warning, err := phpStore.BestVersionForDir(projectDir)
-		if err == nil {
-			terminal.Printfln("    The Web server is using <info>%s %s</> (from %s)", version.ServerTypeName(), version.Version, source)
-			if warning != "" {
-				terminal.Printfln("    <warning>WARNING</> %s", warning)
+		terminal.Printfln("    Listening on <href=%s>%s</>", status.URL, status.URL)
+		if usage := status.Process.String(); usage != "" {
+			terminal.Printfln("    PID <info>%d</>: %s", status.Process.Pid, usage)
+		}
+		if status.PHP != "" {
+			terminal.Printfln("    The Web server is using <info>%s</> (from %s)", status.PHP, status.PHPSource)
+			if status.PHPWarning != "" {
+				terminal.Printfln("    <warning>WARNING</> %s", status.PHPWarning)
 			}
 		}
 		terminal.Println()
 		terminal.Println("<info>Local Domains</>")
-		if proxyConf, err := proxy.Load(util.GetHomeDir()); err == nil {
-			for _, domain := range proxyConf.GetDomains(projectDir) {
-				terminal.Printfln("    <href=%s://%s>%s://%s</>", pidFile.Scheme, domain, pidFile.Scheme, domain)
-			}
+		for _, domain := range status.Domains {
+			terminal.Printfln("    <href=%s>%s</>", domain, domain)
 		}
 	}
 
 	// workers
 	terminal.Println()
 	terminal.Println("<info>Workers</info>")
-	if len(workers) == 0 {
+	if len(status.Workers) == 0 {
 		terminal.Println("    <warning>No Workers</>")
 	} else {
-		for _, p := range workers {
-			msg := fmt.Sprintf(`    PID <info>%d</>: %s`, p.Pid, p.Command())
+		for _, p := range status.Workers {
+			msg := fmt.Sprintf(`    PID <info>%d</>: %s`, p.Pid, p.Command)
+			if p.Name != "" {
+				msg += fmt.Sprintf(" [<comment>%s</>]", p.Name)
+			}
 			if len(p.Watched) > 0 {
 				msg += fmt.Sprintf(" (watching <comment>%s/</comment>)", strings.Join(p.Watched, "/, "))
 			}
 			terminal.Println(msg)
+			if usage := p.String(); usage != "" {
+				terminal.Printfln("        %s", usage)
+			}
 		}
 	}
 
 	// env vars
 	terminal.Println()
 	terminal.Println("<info>Environment Variables</>")
-	data, err := envs.GetEnv(projectDir, terminal.IsDebug())
-	if err != nil {
-		return err
-	}
-	env := envs.AsMap(data)
-	envVars := `<comment>None</>`
-	if env["SYMFONY_TUNNEL"] != "" && env["SYMFONY_TUNNEL_ENV"] != "" {
-		envVars = `Exposed from <info>Platform.sh</>`
-	}
-	if env["SYMFONY_DOCKER_ENV"] == "1" && env["SYMFONY_TUNNEL_ENV"] == "" {
-		envVars = `Exposed from <info>Docker</>`
+	switch status.Environment {
+	case "None":
+		terminal.Println("    <comment>None</>")
+	default:
+		terminal.Printfln("    Exposed from <info>%s</>", status.Environment)
 	}
-	terminal.Printfln("    %s", envVars)
 
 	return nil
 }
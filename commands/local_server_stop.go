@@ -24,6 +24,7 @@ import (
 
 	"github.com/symfony-cli/console"
 	"github.com/symfony-cli/symfony-cli/local/pid"
+	"github.com/symfony-cli/symfony-cli/local/supervisor"
 	"github.com/symfony-cli/terminal"
 	"golang.org/x/sync/errgroup"
 )
@@ -50,6 +51,10 @@ var localServerStopCmd = &console.Command{
 			terminal.Printf("Stopping <comment>%s</>", p.ShortName())
 			if p.IsRunning() {
 				running++
+				// the supervisor must not restart the web server once stopped
+				if err := supervisor.Release(p.Pid); err != nil {
+					return err
+				}
 				g.Go(p.Stop)
 				terminal.Println("")
 			} else {
@@ -61,6 +66,9 @@ var localServerStopCmd = &console.Command{
 		if err := g.Wait(); err != nil {
 			return err
 		}
+		if err := stopDockerServices(projectDir); err != nil {
+			return err
+		}
 		if running == 0 {
 			ui.Success("The web server is not running")
 		} else {
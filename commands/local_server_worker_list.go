@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/symfony-cli/console"
+	"github.com/symfony-cli/symfony-cli/local/pid"
+	"github.com/symfony-cli/symfony-cli/local/project"
+	"github.com/symfony-cli/terminal"
+)
+
+var localServerWorkerListCmd = &console.Command{
+	Category: "local",
+	Name:     "server:worker:list",
+	Aliases:  []*console.Alias{{Name: "server:worker:list"}},
+	Usage:    "List the workers of the project and their status",
+	Flags: []console.Flag{
+		dirFlag,
+	},
+	Action: func(c *console.Context) error {
+		projectDir, err := getProjectDir(c.String("dir"))
+		if err != nil {
+			return err
+		}
+
+		_, fileConfig, err := project.NewConfigFromContext(c, projectDir)
+		if err != nil {
+			return err
+		}
+
+		table := tablewriter.NewWriter(terminal.Stdout)
+		table.SetAutoFormatHeaders(false)
+		table.SetAutoWrapText(false)
+		table.SetHeader([]string{terminal.Format("<header>Name</>"), terminal.Format("<header>Command</>"), terminal.Format("<header>Status</>")})
+
+		listed := map[string]bool{}
+		if fileConfig != nil {
+			names := make([]string, 0, len(fileConfig.Workers))
+			for name := range fileConfig.Workers {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				worker := fileConfig.Workers[name]
+				pidFiles := runningWorkerPidFiles(projectDir, worker)
+				status := terminal.Format("<comment>Not running</>")
+				if len(pidFiles) > 0 {
+					pids := make([]string, 0, len(pidFiles))
+					for _, p := range pidFiles {
+						pids = append(pids, fmt.Sprint(p.Pid))
+						listed[p.PidFile()] = true
+					}
+					status = terminal.Formatf("<info>Running</> (PID %s)", strings.Join(pids, ", "))
+				}
+				table.Append([]string{name, strings.Join(worker.Cmd, " "), status})
+			}
+		}
+
+		// commands run via "run -d" not defined as workers (named ones are PHP or workers)
+		for _, p := range pid.AllWorkers(projectDir) {
+			if listed[p.PidFile()] || p.CustomName != "" {
+				continue
+			}
+			table.Append([]string{"", p.Command(), terminal.Formatf("<info>Running</> (PID %d)", p.Pid)})
+		}
+
+		table.Render()
+		return nil
+	},
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/symfony-cli/console"
+	"github.com/symfony-cli/symfony-cli/local/pid"
+	"github.com/symfony-cli/terminal"
+)
+
+var localServerWorkerRestartCmd = &console.Command{
+	Category: "local",
+	Name:     "server:worker:restart",
+	Aliases:  []*console.Alias{{Name: "server:worker:restart"}},
+	Usage:    "Restart a worker defined in .symfony.local.yaml",
+	Flags: []console.Flag{
+		dirFlag,
+	},
+	Args: []*console.Arg{
+		{Name: "name", Description: "The worker name as defined in .symfony.local.yaml"},
+	},
+	Action: func(c *console.Context) error {
+		projectDir, err := getProjectDir(c.String("dir"))
+		if err != nil {
+			return err
+		}
+
+		name := c.Args().Get("name")
+		worker, err := loadWorkerConfig(c, projectDir, name)
+		if err != nil {
+			return err
+		}
+
+		if !pid.New(projectDir, nil).IsRunning() {
+			for _, p := range runningWorkerPidFiles(projectDir, worker) {
+				if err := p.Stop(); err != nil {
+					return err
+				}
+			}
+			return runWorkerInBackground(c, projectDir, name, worker)
+		}
+
+		if err := sendWorkerRequest(projectDir, &workerRequest{Action: workerActionRestart, Worker: name}); err != nil {
+			return err
+		}
+
+		terminal.SymfonyStyle(terminal.Stdout, terminal.Stdin).Success(fmt.Sprintf("Restarted worker \"%s\"", name))
+		return nil
+	},
+}
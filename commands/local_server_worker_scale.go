@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package commands
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/symfony-cli/console"
+	"github.com/symfony-cli/symfony-cli/local/pid"
+	"github.com/symfony-cli/terminal"
+)
+
+var localServerWorkerScaleCmd = &console.Command{
+	Category: "local",
+	Name:     "server:worker:scale",
+	Aliases:  []*console.Alias{{Name: "server:worker:scale"}},
+	Usage:    "Change the number of replicas of a worker of the running local web server",
+	Flags: []console.Flag{
+		dirFlag,
+	},
+	Args: []*console.Arg{
+		{Name: "name", Description: "The worker name as defined in .symfony.local.yaml"},
+		{Name: "replicas", Description: "The number of replicas to run"},
+	},
+	Action: func(c *console.Context) error {
+		projectDir, err := getProjectDir(c.String("dir"))
+		if err != nil {
+			return err
+		}
+
+		name := c.Args().Get("name")
+		replicas, err := strconv.Atoi(c.Args().Get("replicas"))
+		if err != nil || replicas < 0 {
+			return errors.Errorf("The number of replicas must be a positive integer, got \"%s\"", c.Args().Get("replicas"))
+		}
+
+		if _, err := loadWorkerConfig(c, projectDir, name); err != nil {
+			return err
+		}
+
+		if !pid.New(projectDir, nil).IsRunning() {
+			return errors.New("The local web server is not running")
+		}
+
+		if err := sendWorkerRequest(projectDir, &workerRequest{Action: workerActionScale, Worker: name, Replicas: replicas}); err != nil {
+			return err
+		}
+
+		terminal.SymfonyStyle(terminal.Stdout, terminal.Stdin).Success(fmt.Sprintf("Worker \"%s\" was scaled to %d replica(s)", name, replicas))
+		terminal.Printfln("Check the result via <info>%s server:status</>", c.App.HelpName)
+		return nil
+	},
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/symfony-cli/console"
+	"github.com/symfony-cli/symfony-cli/local/pid"
+	"github.com/symfony-cli/terminal"
+)
+
+var localServerWorkerStopCmd = &console.Command{
+	Category: "local",
+	Name:     "server:worker:stop",
+	Aliases:  []*console.Alias{{Name: "server:worker:stop"}},
+	Usage:    "Stop a worker defined in .symfony.local.yaml",
+	Flags: []console.Flag{
+		dirFlag,
+	},
+	Args: []*console.Arg{
+		{Name: "name", Description: "The worker name as defined in .symfony.local.yaml"},
+	},
+	Action: func(c *console.Context) error {
+		projectDir, err := getProjectDir(c.String("dir"))
+		if err != nil {
+			return err
+		}
+
+		name := c.Args().Get("name")
+		worker, err := loadWorkerConfig(c, projectDir, name)
+		if err != nil {
+			return err
+		}
+
+		if pid.New(projectDir, nil).IsRunning() {
+			if err := sendWorkerRequest(projectDir, &workerRequest{Action: workerActionStop, Worker: name}); err != nil {
+				return err
+			}
+		} else {
+			pidFiles := runningWorkerPidFiles(projectDir, worker)
+			if len(pidFiles) == 0 {
+				terminal.SymfonyStyle(terminal.Stdout, terminal.Stdin).Success(fmt.Sprintf("Worker \"%s\" is not running", name))
+				return nil
+			}
+			for _, p := range pidFiles {
+				if err := p.Stop(); err != nil {
+					return err
+				}
+			}
+		}
+
+		terminal.SymfonyStyle(terminal.Stdout, terminal.Stdin).Success(fmt.Sprintf("Stopped worker \"%s\"", name))
+		return nil
+	},
+}
@@ -0,0 +1,571 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/xid"
+	"github.com/symfony-cli/console"
+	"github.com/symfony-cli/symfony-cli/envs"
+	"github.com/symfony-cli/symfony-cli/inotify"
+	"github.com/symfony-cli/symfony-cli/local"
+	"github.com/symfony-cli/symfony-cli/local/pid"
+	"github.com/symfony-cli/symfony-cli/local/project"
+	"github.com/symfony-cli/symfony-cli/util"
+	"github.com/symfony-cli/terminal"
+)
+
+// workersManager runs the workers defined in ".symfony.local.yaml" on
+// behalf of the local web server
+type workersManager struct {
+	projectDir string
+	errChan    chan error
+
+	mu      sync.Mutex
+	workers map[string]*project.Worker
+	states  map[string]*workerState
+	runners map[string][]*local.Runner
+}
+
+type workerState struct {
+	// closed once the worker is ready or has failed to start
+	ready chan bool
+	// must only be read once ready is closed
+	failed bool
+}
+
+func (s *workerState) markAsReady() {
+	close(s.ready)
+}
+
+func (s *workerState) markAsFailed() {
+	s.failed = true
+	close(s.ready)
+}
+
+func newWorkersManager(projectDir string, workers map[string]*project.Worker, errChan chan error) *workersManager {
+	return &workersManager{
+		projectDir: projectDir,
+		workers:    workers,
+		errChan:    errChan,
+		states:     make(map[string]*workerState),
+		runners:    make(map[string][]*local.Runner),
+	}
+}
+
+// start starts workers, each one once the workers it depends on are ready
+func (m *workersManager) start(names []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// states must all exist before any worker waits on them
+	for _, name := range names {
+		m.states[name] = &workerState{ready: make(chan bool)}
+	}
+	for _, name := range names {
+		worker := m.workers[name]
+		deps := make([]*workerState, 0, len(worker.DependsOn))
+		for _, dep := range worker.DependsOn {
+			deps = append(deps, m.states[dep])
+		}
+		// we run each worker in its own goroutine for several reasons:
+		// * to get things up and running faster
+		// * to allow all commands to run when foreground is forced
+		go m.startWorker(name, worker, m.states[name], deps)
+	}
+}
+
+func (m *workersManager) startWorker(name string, worker *project.Worker, state *workerState, deps []*workerState) {
+	for i, dep := range deps {
+		<-dep.ready
+		if dep.failed {
+			terminal.Eprintfln("<warning>WARNING</> Unable to start worker \"%s\": it depends on worker \"%s\" which is not ready", name, worker.DependsOn[i])
+			state.markAsFailed()
+			return
+		}
+	}
+
+	env, err := m.env()
+	if err != nil {
+		state.markAsFailed()
+		m.errChan <- err
+		return
+	}
+
+	m.mu.Lock()
+	var first *pid.PidFile
+	var stopped <-chan bool
+	for replica := 1; replica <= worker.ReplicasCount(); replica++ {
+		if pidFile := m.startReplica(name, worker, replica, env); replica == 1 {
+			first = pidFile
+			if runners := m.runners[name]; first != nil && len(runners) > 0 && runners[0] != nil {
+				stopped = runners[0].Stopped()
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	if first == nil {
+		state.markAsFailed()
+		return
+	}
+	if worker.Readiness == nil {
+		state.markAsReady()
+		return
+	}
+	if err := worker.Readiness.Wait(first, env, stopped); err != nil {
+		terminal.Eprintfln("<warning>WARNING</> Worker \"%s\" is not ready: %s", name, err)
+		state.markAsFailed()
+		return
+	}
+	terminal.SymfonyStyle(terminal.Stdout, terminal.Stdin).Success(fmt.Sprintf("Worker \"%s\" is ready", name))
+	state.markAsReady()
+}
+
+// startReplica starts an instance of a worker, m.mu must be locked
+func (m *workersManager) startReplica(name string, worker *project.Worker, replica int, env []string) *pid.PidFile {
+	displayName := name
+	if replica > 1 {
+		displayName = fmt.Sprintf("%s#%d", name, replica)
+	}
+
+	pidFile := pid.NewReplica(m.projectDir, worker.Cmd, replica)
+	if pidFile.IsRunning() {
+		terminal.Eprintfln("<warning>WARNING</> Unable to start worker \"%s\": it is already running for this project as PID %d", displayName, pidFile.Pid)
+		m.runners[name] = append(m.runners[name], nil)
+		return pidFile
+	}
+	pidFile.Watched = worker.Watch
+	pidFile.CustomName = displayName
+	pidFile.StopSignal = worker.StopSignal
+	pidFile.StopTimeout = worker.StopTimeout
+
+	runner, err := local.NewRunner(pidFile, local.RunnerModeLoopAttached)
+	if err != nil {
+		terminal.Eprintfln("<warning>WARNING</> Unable to start worker \"%s\": %s", displayName, err)
+		return nil
+	}
+	configureWorkerRunner(runner, worker, pidFile.Dir, func() ([]string, error) { return env, nil })
+	m.runners[name] = append(m.runners[name], runner)
+
+	go func() {
+		terminal.SymfonyStyle(terminal.Stdout, terminal.Stdin).Success(fmt.Sprintf("Started worker \"%s\"", displayName))
+		if err := runner.Run(); err != nil {
+			terminal.Eprintfln("<warning>WARNING</> Worker \"%s\" exited with an error: %s", displayName, err)
+		}
+	}()
+
+	return pidFile
+}
+
+// reload stops the removed and changed workers and starts the added and
+// changed ones; the workers depending on a changed worker are restarted as
+// well so that they are started again once it is ready
+func (m *workersManager) reload(config *project.FileConfig, added, removed, changed []string) error {
+	names, err := config.WorkersStartOrder()
+	if err != nil {
+		return err
+	}
+
+	toRestart := map[string]bool{}
+	for _, name := range changed {
+		toRestart[name] = true
+	}
+	m.mu.Lock()
+	for _, name := range config.WorkerDependents(changed) {
+		if _, ok := m.workers[name]; ok {
+			toRestart[name] = true
+		}
+	}
+	m.mu.Unlock()
+
+	// dependents are stopped before the workers they depend on
+	toStop := append([]string{}, removed...)
+	for i := len(names) - 1; i >= 0; i-- {
+		if toRestart[names[i]] {
+			toStop = append(toStop, names[i])
+		}
+	}
+	for _, name := range toStop {
+		if err := m.scale(name, 0); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	for _, name := range removed {
+		delete(m.states, name)
+		delete(m.runners, name)
+	}
+	m.workers = config.Workers
+	m.mu.Unlock()
+
+	for _, name := range added {
+		toRestart[name] = true
+	}
+	startNames := []string{}
+	for _, name := range names {
+		if toRestart[name] {
+			startNames = append(startNames, name)
+		}
+	}
+	m.start(startNames)
+
+	return nil
+}
+
+// configureWorkerRunner applies the worker configuration to its runner
+func configureWorkerRunner(runner *local.Runner, worker *project.Worker, projectDir string, env func() ([]string, error)) {
+	runner.RestartPolicy = worker.Restart
+	runner.MaxRetries = worker.MaxRetries
+	runner.RestartDelay = worker.RestartDelay
+	runner.MaxRestartDelay = worker.MaxRestartDelay
+	runner.WatchOptions = worker.WatchOptions
+	runner.BuildCmdHook = func(cmd *exec.Cmd) error {
+		vars, err := env()
+		if err != nil {
+			return err
+		}
+
+		cmd.Dir = worker.WorkDir(projectDir)
+		cmd.Env = append(cmd.Env, vars...)
+		for k, v := range worker.Env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+
+		return nil
+	}
+}
+
+// scale starts or stops instances of a worker to run the given number of replicas
+func (m *workersManager) scale(name string, replicas int) error {
+	if replicas < 0 {
+		return errors.Errorf("the number of replicas of worker \"%s\" cannot be negative", name)
+	}
+
+	// the environment is only needed to start replicas, stopping them must
+	// work even when it cannot be computed (when Docker is down for instance)
+	var env []string
+	m.mu.Lock()
+	starting := replicas > len(m.runners[name])
+	m.mu.Unlock()
+	if starting {
+		var err error
+		if env, err = m.env(); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	worker, ok := m.workers[name]
+	if !ok {
+		m.mu.Unlock()
+		return errors.Errorf("worker \"%s\" is not defined", name)
+	}
+
+	current := len(m.runners[name])
+	if current == replicas {
+		m.mu.Unlock()
+		return nil
+	}
+	if replicas > current && !starting {
+		// replicas have been stopped concurrently
+		m.mu.Unlock()
+		return m.scale(name, replicas)
+	}
+	terminal.Eprintfln("Scaling worker \"%s\" from %d to %d replica(s)", name, current, replicas)
+	for replica := current + 1; replica <= replicas; replica++ {
+		m.startReplica(name, worker, replica, env)
+	}
+	// stopping a replica can take up to its stop timeout, so it is done
+	// without holding the lock
+	var runners []*local.Runner
+	var pidFiles []*pid.PidFile
+	for replica := current; replica > replicas; replica-- {
+		if runner := m.runners[name][replica-1]; runner != nil {
+			runners = append(runners, runner)
+		} else {
+			pidFiles = append(pidFiles, pid.NewReplica(m.projectDir, worker.Cmd, replica))
+		}
+		m.runners[name] = m.runners[name][:replica-1]
+	}
+	m.mu.Unlock()
+
+	for _, runner := range runners {
+		runner.Stop()
+	}
+	for _, pidFile := range pidFiles {
+		if pidFile.IsRunning() {
+			if err := pidFile.Stop(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// watchRequests handles the requests sent by the server:worker:* commands
+func (m *workersManager) watchRequests() error {
+	dir := workersRequestsDir(m.projectDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	c := make(chan inotify.EventInfo, 10)
+	if err := inotify.Watch(dir, c, inotify.Create); err != nil {
+		return errors.Wrap(err, "unable to watch the workers requests directory")
+	}
+	go func() {
+		for e := range c {
+			if filepath.Ext(e.Path()) != ".json" {
+				continue
+			}
+			req, err := readWorkerRequest(e.Path())
+			if err != nil {
+				terminal.Eprintfln("<warning>WARNING</> Unable to read the worker request: %s", err)
+			} else if err = m.handle(req); err != nil {
+				terminal.Eprintfln("<warning>WARNING</> Unable to %s worker \"%s\": %s", req.Action, req.Worker, err)
+			}
+			// the response tells the sender the request has been handled
+			if err := writeWorkerResponse(e.Path(), err); err != nil {
+				terminal.Eprintfln("<warning>WARNING</> Unable to answer the worker request: %s", err)
+			}
+			os.Remove(e.Path())
+		}
+	}()
+	return nil
+}
+
+func (m *workersManager) handle(req *workerRequest) error {
+	m.mu.Lock()
+	worker, ok := m.workers[req.Worker]
+	m.mu.Unlock()
+	if !ok {
+		return errors.Errorf("worker \"%s\" is not defined", req.Worker)
+	}
+
+	switch req.Action {
+	case workerActionStart:
+		m.mu.Lock()
+		running := len(m.runners[req.Worker])
+		m.mu.Unlock()
+		if running > 0 {
+			return errors.New("it is already running")
+		}
+		return m.scale(req.Worker, worker.ReplicasCount())
+	case workerActionStop:
+		return m.scale(req.Worker, 0)
+	case workerActionRestart:
+		m.mu.Lock()
+		replicas := len(m.runners[req.Worker])
+		m.mu.Unlock()
+		if replicas == 0 {
+			replicas = worker.ReplicasCount()
+		}
+		if err := m.scale(req.Worker, 0); err != nil {
+			return err
+		}
+		return m.scale(req.Worker, replicas)
+	case workerActionScale:
+		return m.scale(req.Worker, req.Replicas)
+	}
+
+	return errors.Errorf("unknown action \"%s\"", req.Action)
+}
+
+func (m *workersManager) env() ([]string, error) {
+	env, err := envs.GetEnv(m.projectDir, terminal.IsDebug())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return envs.AsSlice(env), nil
+}
+
+const (
+	workerActionStart   = "start"
+	workerActionStop    = "stop"
+	workerActionRestart = "restart"
+	workerActionScale   = "scale"
+)
+
+// workerRequest is sent by the server:worker:* commands to the running local web server
+type workerRequest struct {
+	Action   string `json:"action"`
+	Worker   string `json:"worker"`
+	Replicas int    `json:"replicas,omitempty"`
+}
+
+func workersRequestsDir(projectDir string) string {
+	return filepath.Join(util.GetHomeDir(), "var", pid.New(projectDir, nil).Name()+".requests")
+}
+
+// sendWorkerRequest sends a request to the running local web server and
+// waits for it to be handled
+func sendWorkerRequest(projectDir string, req *workerRequest) error {
+	dir := workersRequestsDir(projectDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	b, err := json.Marshal(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// write then rename to be sure the server never reads a partial request
+	path := filepath.Join(dir, xid.New().String()+".json")
+	if err := ioutil.WriteFile(path+".tmp", b, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return errors.WithStack(err)
+	}
+
+	// stopping workers can take some time as they are given time to finish their job
+	responsePath := workerResponsePath(path)
+	for deadline := time.Now().Add(2 * time.Minute); time.Now().Before(deadline); {
+		if contents, err := ioutil.ReadFile(responsePath); err == nil {
+			os.Remove(responsePath)
+			var resp workerResponse
+			if err := json.Unmarshal(contents, &resp); err != nil {
+				return errors.WithStack(err)
+			}
+			if resp.Error != "" {
+				return errors.New(resp.Error)
+			}
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	os.Remove(path)
+	return errors.New("The local web server did not handle the request, is it running?")
+}
+
+// workerResponse is written by the local web server once a request is handled
+type workerResponse struct {
+	Error string `json:"error,omitempty"`
+}
+
+func workerResponsePath(requestPath string) string {
+	return strings.TrimSuffix(requestPath, ".json") + ".response"
+}
+
+func writeWorkerResponse(requestPath string, handleErr error) error {
+	var resp workerResponse
+	if handleErr != nil {
+		resp.Error = handleErr.Error()
+	}
+	b, err := json.Marshal(resp)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	path := workerResponsePath(requestPath)
+	if err := ioutil.WriteFile(path+".tmp", b, 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(path+".tmp", path))
+}
+
+func readWorkerRequest(path string) (*workerRequest, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var req *workerRequest
+	if err := json.Unmarshal(contents, &req); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return req, nil
+}
+
+// loadWorkerConfig returns the configuration of a worker defined in .symfony.local.yaml
+func loadWorkerConfig(c *console.Context, projectDir, name string) (*project.Worker, error) {
+	_, fileConfig, err := project.NewConfigFromContext(c, projectDir)
+	if err != nil {
+		return nil, err
+	}
+	if fileConfig == nil || fileConfig.Workers[name] == nil {
+		return nil, errors.Errorf("The \"%s\" worker is not defined in \".symfony.local.yaml\"", name)
+	}
+	return fileConfig.Workers[name], nil
+}
+
+// runningWorkerPidFiles returns the pid files of the running instances of a
+// worker, started by the local web server or via "run -d"
+func runningWorkerPidFiles(projectDir string, worker *project.Worker) []*pid.PidFile {
+	command := strings.Join(worker.Cmd, " ")
+	pidFiles := []*pid.PidFile{}
+	for _, p := range pid.AllWorkers(projectDir) {
+		if p.Command() == command {
+			pidFiles = append(pidFiles, p)
+		}
+	}
+	sort.Slice(pidFiles, func(i, j int) bool { return pidFiles[i].Replica < pidFiles[j].Replica })
+	return pidFiles
+}
+
+// runWorkerInBackground runs a worker like "run -d" does when the local web
+// server is not running
+func runWorkerInBackground(c *console.Context, projectDir, name string, worker *project.Worker) error {
+	if worker.ReplicasCount() > 1 {
+		terminal.Eprintfln("<warning>WARNING</> Only one replica of worker \"%s\" can be started when the local web server is not running", name)
+	}
+	pidFile := pid.New(projectDir, worker.Cmd)
+	if pidFile.IsRunning() {
+		return errors.Errorf("Unable to start worker \"%s\": it is already running for this project as PID %d", name, pidFile.Pid)
+	}
+	pidFile.Watched = worker.Watch
+	pidFile.CustomName = name
+	pidFile.StopSignal = worker.StopSignal
+	pidFile.StopTimeout = worker.StopTimeout
+
+	runner, err := local.NewRunner(pidFile, local.RunnerModeLoopDetached)
+	if err != nil {
+		return err
+	}
+	configureWorkerRunner(runner, worker, pidFile.Dir, func() ([]string, error) {
+		env, err := envs.GetEnv(pidFile.Dir, terminal.IsDebug())
+		if err != nil {
+			return nil, err
+		}
+		return envs.AsSlice(env), nil
+	})
+
+	if err := runner.Run(); err != nil {
+		if _, wentToBackground := err.(local.RunnerWentToBackground); wentToBackground {
+			terminal.SymfonyStyle(terminal.Stdout, terminal.Stdin).Success(fmt.Sprintf("Started worker \"%s\"", name))
+			terminal.Printfln("Stream the logs via <info>%s server:log</>", c.App.HelpName)
+			return nil
+		}
+
+		return err
+	}
+
+	return nil
+}
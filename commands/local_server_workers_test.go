@@ -0,0 +1,75 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+)
+
+func TestSendWorkerRequestReturnsServerError(t *testing.T) {
+	home, err := ioutil.TempDir("", "symfony-workers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	oldHome := os.Getenv("HOME")
+	homedir.Reset()
+	os.Setenv("HOME", home)
+	defer func() {
+		os.Setenv("HOME", oldHome)
+		homedir.Reset()
+	}()
+
+	// a fake server answering every request like the workers manager does
+	dir := workersRequestsDir("/project")
+	go func() {
+		for {
+			requests, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+			for _, path := range requests {
+				req, err := readWorkerRequest(path)
+				if err != nil {
+					continue
+				}
+				var handleErr error
+				if req.Worker == "unknown" {
+					handleErr = errors.New("worker \"unknown\" is not defined")
+				}
+				writeWorkerResponse(path, handleErr)
+				os.Remove(path)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	if err := sendWorkerRequest("/project", &workerRequest{Action: workerActionStart, Worker: "messenger"}); err != nil {
+		t.Errorf("expected the request to succeed, got %s", err)
+	}
+	err = sendWorkerRequest("/project", &workerRequest{Action: workerActionStart, Worker: "unknown"})
+	if err == nil || err.Error() != "worker \"unknown\" is not defined" {
+		t.Errorf("expected the server error to be returned, got %v", err)
+	}
+}
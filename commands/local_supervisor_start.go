@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/symfony-cli/console"
+	"github.com/symfony-cli/symfony-cli/local/supervisor"
+	"github.com/symfony-cli/symfony-cli/reexec"
+	"github.com/symfony-cli/symfony-cli/util"
+	"github.com/symfony-cli/terminal"
+)
+
+var localSupervisorStartCmd = &console.Command{
+	Category: "local",
+	Name:     "supervisor:start",
+	Aliases:  []*console.Alias{{Name: "supervisor:start"}},
+	Usage:    "Start the supervisor that runs all background servers and workers",
+	Flags: []console.Flag{
+		&console.BoolFlag{Name: "foreground", Usage: "Run the supervisor in the foreground"},
+	},
+	Action: func(c *console.Context) error {
+		ui := terminal.SymfonyStyle(terminal.Stdout, terminal.Stdin)
+		pidFile := supervisor.PidFile()
+		if pidFile.IsRunning() {
+			ui.Success(fmt.Sprintf("The supervisor is already running as PID %d", pidFile.Pid))
+			return nil
+		}
+
+		if !c.Bool("foreground") && !reexec.IsChild() {
+			varDir := filepath.Join(util.GetHomeDir(), "var")
+			if err := os.MkdirAll(varDir, 0755); err != nil {
+				return errors.Wrap(err, "Could not create status file")
+			}
+			if err := reexec.Background(varDir); err != nil {
+				if _, isExitCoder := err.(console.ExitCoder); isExitCoder {
+					return err
+				}
+				terminal.Printfln("Impossible to go to the background: %s", err)
+				terminal.Println("Continue in foreground")
+			} else {
+				return nil
+			}
+		}
+
+		if err := reexec.NotifyForeground("boot"); err != nil {
+			return console.Exit(fmt.Sprintf("Unable to go to the background: %s, aborting", err), 1)
+		}
+
+		lw, err := pidFile.LogWriter()
+		if err != nil {
+			return err
+		}
+		s := supervisor.New(lw)
+		if err := s.Listen(); err != nil {
+			return err
+		}
+		if err := pidFile.Write(os.Getpid(), 0, ""); err != nil {
+			return err
+		}
+		defer pidFile.Remove()
+
+		ui.Success("The supervisor is running, background servers and workers are now started through it")
+		if reexec.IsChild() {
+			terminal.RemapOutput(lw, lw).SetDecorated(true)
+			reexec.NotifyForeground(reexec.UP)
+		}
+
+		stopCh := make(chan bool, 1)
+		go func() {
+			sigsCh := make(chan os.Signal, 1)
+			signal.Notify(sigsCh, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
+			<-sigsCh
+			signal.Stop(sigsCh)
+			stopCh <- true
+		}()
+
+		return s.Serve(stopCh)
+	},
+}
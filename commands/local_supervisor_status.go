@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/symfony-cli/console"
+	"github.com/symfony-cli/symfony-cli/local/supervisor"
+	"github.com/symfony-cli/terminal"
+)
+
+var localSupervisorStatusCmd = &console.Command{
+	Category: "local",
+	Name:     "supervisor:status",
+	Aliases:  []*console.Alias{{Name: "supervisor:status"}},
+	Usage:    "Get the supervisor status and the processes it runs",
+	Action: func(c *console.Context) error {
+		terminal.Println("<info>Supervisor</>")
+
+		pidFile := supervisor.PidFile()
+		if !pidFile.IsRunning() {
+			terminal.Println("    <error>Not Running</>")
+			return nil
+		}
+		terminal.Printfln("    Running as PID <info>%d</> (logs in %s)", pidFile.Pid, pidFile.LogFile())
+
+		processes, err := supervisor.List()
+		if err != nil {
+			return err
+		}
+		terminal.Println()
+		terminal.Println("<info>Processes</>")
+		if len(processes) == 0 {
+			terminal.Println("    <warning>No Processes</>")
+			return nil
+		}
+
+		table := tablewriter.NewWriter(terminal.Stdout)
+		table.SetAutoFormatHeaders(false)
+		table.SetAutoWrapText(false)
+		table.SetHeader([]string{terminal.Format("<header>PID</>"), terminal.Format("<header>Directory</>"), terminal.Format("<header>Command</>"), terminal.Format("<header>Restarts</>"), terminal.Format("<header>Uptime</>")})
+		for _, p := range processes {
+			// the first argument is the path to the binary
+			table.Append([]string{
+				terminal.Formatf("<info>%d</>", p.Pid),
+				p.Dir,
+				strings.Join(p.Args[1:], " "),
+				fmt.Sprint(p.Restarts),
+				time.Since(p.StartedAt).Round(time.Second).String(),
+			})
+		}
+		table.Render()
+		return nil
+	},
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package commands
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/symfony-cli/console"
+	"github.com/symfony-cli/symfony-cli/local/supervisor"
+	"github.com/symfony-cli/terminal"
+)
+
+var localSupervisorStopCmd = &console.Command{
+	Category: "local",
+	Name:     "supervisor:stop",
+	Aliases:  []*console.Alias{{Name: "supervisor:stop"}},
+	Usage:    "Stop the supervisor and all the servers and workers it runs",
+	Action: func(c *console.Context) error {
+		ui := terminal.SymfonyStyle(terminal.Stdout, terminal.Stdin)
+		pidFile := supervisor.PidFile()
+		if !pidFile.IsRunning() {
+			ui.Success("The supervisor is not running")
+			return nil
+		}
+
+		if err := supervisor.Shutdown(); err != nil {
+			return err
+		}
+		for deadline := time.Now().Add(30 * time.Second); pidFile.IsRunning(); {
+			if time.Now().After(deadline) {
+				return errors.New("The supervisor did not stop in time")
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		ui.Success("Stopped the supervisor successfully")
+		return nil
+	},
+}
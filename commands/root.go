@@ -60,6 +60,11 @@ func CommonCommands() []*console.Command {
 		bookCheckReqsCmd,
 		bookCheckoutCmd,
 		cloudEnvDebugCmd,
+		localBootCmd,
+		localDockerDownCmd,
+		localDockerPsCmd,
+		localDockerUpCmd,
+		localHooksRunCmd,
 		localNewCmd,
 		localPhpListCmd,
 		localPhpRefreshCmd,
@@ -70,6 +75,7 @@ func CommonCommands() []*console.Command {
 		localProxyStopCmd,
 		localRequirementsCheckCmd,
 		localRunCmd,
+		localServerAutostartCmd,
 		localServerCAInstallCmd,
 		localServerCAUninstallCmd,
 		localServerListCmd,
@@ -78,6 +84,14 @@ func CommonCommands() []*console.Command {
 		localServerStartCmd,
 		localServerStatusCmd,
 		localServerStopCmd,
+		localServerWorkerListCmd,
+		localServerWorkerRestartCmd,
+		localServerWorkerScaleCmd,
+		localServerWorkerStartCmd,
+		localServerWorkerStopCmd,
+		localSupervisorStartCmd,
+		localSupervisorStatusCmd,
+		localSupervisorStopCmd,
 		localVariableExposeFromTunnelCmd,
 		localSecurityCheckCmd,
 		projectLocalMailCatcherOpenCmd,
@@ -20,10 +20,8 @@
 package envs
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"io/ioutil"
 	"net"
 	"net/url"
 	"os"
@@ -34,8 +32,10 @@ import (
 	"strings"
 	"time"
 
+	compose "github.com/compose-spec/compose-go/cli"
 	"github.com/docker/docker/api/types"
 	docker "github.com/docker/docker/client"
+	"github.com/pkg/errors"
 	"github.com/symfony-cli/terminal"
 )
 
@@ -44,6 +44,104 @@ var (
 	dockerComposeNormalizeRegexpLegacy = regexp.MustCompile("[^a-z0-9]")
 )
 
+// dockerServiceDefaultPorts lists the service types supported by the
+// com.symfony.server.service-type label with their default private port
+var dockerServiceDefaultPorts = map[string]uint16{
+	"blackfire":     8307,
+	"clickhouse":    8123,
+	"elasticsearch": 9200,
+	"kafka":         9092,
+	"maildev":       25,
+	"mailer":        1025,
+	"meilisearch":   7700,
+	"memcached":     11211,
+	"mercure":       80,
+	"mongodb":       27017,
+	"mysql":         3306,
+	"opensearch":    9200,
+	"postgresql":    5432,
+	"rabbitmq":      5672,
+	"redis":         6379,
+	"s3":            9000,
+	"typesense":     8108,
+	"varnish":       80,
+}
+
+var dockerServiceTypeAliases = map[string]string{
+	"amqp":        "rabbitmq",
+	"mailcatcher": "mailer",
+	"mailhog":     "mailer",
+	"mailpit":     "mailer",
+	"mariadb":     "mysql",
+	"minio":       "s3",
+	"mongo":       "mongodb",
+	"pgsql":       "postgresql",
+	"postgres":    "postgresql",
+	"valkey":      "redis",
+}
+
+// dockerPortServiceTypes is used for containers whose type is unknown
+var dockerPortServiceTypes = map[uint16]string{
+	25:    "maildev",
+	1025:  "mailer",
+	3306:  "mysql",
+	5432:  "postgresql",
+	5672:  "rabbitmq",
+	6379:  "redis",
+	7700:  "meilisearch",
+	8108:  "typesense",
+	8123:  "clickhouse",
+	8307:  "blackfire",
+	8707:  "blackfire",
+	9092:  "kafka",
+	9200:  "elasticsearch",
+	11211: "memcached",
+	27017: "mongodb",
+}
+
+// dockerImageServiceTypes maps well-known images to their service type
+var dockerImageServiceTypes = map[string]string{
+	"axllent/mailpit":              "mailer",
+	"bitnami/clickhouse":           "clickhouse",
+	"bitnami/elasticsearch":        "elasticsearch",
+	"bitnami/kafka":                "kafka",
+	"bitnami/mariadb":              "mysql",
+	"bitnami/memcached":            "memcached",
+	"bitnami/minio":                "s3",
+	"bitnami/mongodb":              "mongodb",
+	"bitnami/mysql":                "mysql",
+	"bitnami/opensearch":           "opensearch",
+	"bitnami/postgresql":           "postgresql",
+	"bitnami/rabbitmq":             "rabbitmq",
+	"bitnami/redis":                "redis",
+	"blackfire/blackfire":          "blackfire",
+	"clickhouse/clickhouse-server": "clickhouse",
+	"confluentinc/cp-kafka":        "kafka",
+	"dunglas/mercure":              "mercure",
+	"elasticsearch":                "elasticsearch",
+	"elasticsearch/elasticsearch":  "elasticsearch",
+	"getmeili/meilisearch":         "meilisearch",
+	"mailhog/mailhog":              "mailer",
+	"mariadb":                      "mysql",
+	"memcached":                    "memcached",
+	"minio/minio":                  "s3",
+	"mongo":                        "mongodb",
+	"mysql":                        "mysql",
+	"opensearchproject/opensearch": "opensearch",
+	"percona":                      "mysql",
+	"pgvector/pgvector":            "postgresql",
+	"postgis/postgis":              "postgresql",
+	"postgres":                     "postgresql",
+	"rabbitmq":                     "rabbitmq",
+	"redis":                        "redis",
+	"schickling/mailcatcher":       "mailer",
+	"timescale/timescaledb":        "postgresql",
+	"typesense/typesense":          "typesense",
+	"valkey/valkey":                "redis",
+	"varnish":                      "varnish",
+	"yandex/clickhouse-server":     "clickhouse",
+}
+
 type sortedPorts []types.Port
 
 func (ps sortedPorts) Len() int           { return len(ps) }
@@ -63,12 +161,7 @@ func normalizeDockerComposeProjectNameLegacy(projectName string) string {
 	return dockerComposeNormalizeRegexpLegacy.ReplaceAllString(strings.ToLower(projectName), "")
 }
 
-func (l *Local) RelationshipsFromDocker() Relationships {
-	project := l.getComposeProjectName()
-	if project == "" {
-		return nil
-	}
-
+func newDockerClient() (*docker.Client, error) {
 	opts := [](docker.Opt){docker.FromEnv}
 	if host := os.Getenv("DOCKER_HOST"); host != "" && !strings.HasPrefix(host, "unix://") {
 		// Setting a dialer on top of a unix socket breaks the connection
@@ -81,23 +174,32 @@ func (l *Local) RelationshipsFromDocker() Relationships {
 	}
 	client, err := docker.NewClientWithOpts(opts...)
 	if err != nil {
-		if l.Debug {
-			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
-		}
-		return nil
+		return nil, err
 	}
-	defer client.Close()
-
 	client.NegotiateAPIVersion(context.Background())
+	return client, nil
+}
 
-	containers, err := client.ContainerList(context.Background(), types.ContainerListOptions{})
+// dockerHost returns the host on which Docker containers ports are published
+func dockerHost() string {
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" || strings.HasPrefix(host, "unix://") {
+		return "127.0.0.1"
+	}
+	u, err := url.Parse(host)
 	if err != nil {
-		if docker.IsErrConnectionFailed(err) {
-			terminal.Logger.Warn().Msg(err.Error())
-		} else if l.Debug {
-			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
-		}
-		return nil
+		fmt.Fprintf(os.Stderr, "  ERROR: unable to parse DOCKER_HOST \"%s\", falling back to 127.0.0.1: %s\n", host, err)
+		return "127.0.0.1"
+	}
+	return u.Hostname()
+}
+
+// composeContainers returns the running containers of the given Docker Compose
+// project, or all of them, including the stopped ones, when all is true
+func (l *Local) composeContainers(client *docker.Client, project string, all bool) ([]types.Container, error) {
+	list, err := client.ContainerList(context.Background(), types.ContainerListOptions{All: all})
+	if err != nil {
+		return nil, err
 	}
 
 	// To be in sync with Docker compose behavior we also normalize project name
@@ -105,8 +207,8 @@ func (l *Local) RelationshipsFromDocker() Relationships {
 	projectLegacy := normalizeDockerComposeProjectNameLegacy(project)
 	project = normalizeDockerComposeProjectName(project)
 
-	relationships := Relationships{}
-	for _, container := range containers {
+	var containers []types.Container
+	for _, container := range list {
 		p, ok := container.Labels["com.docker.compose.project"]
 		if !ok {
 			continue
@@ -114,6 +216,60 @@ func (l *Local) RelationshipsFromDocker() Relationships {
 		if p != project && p != projectLegacy {
 			continue
 		}
+		containers = append(containers, container)
+	}
+	return containers, nil
+}
+
+var dockerExitCodeRegexp = regexp.MustCompile(`^Exited \((\d+)\)`)
+
+// dockerContainerError returns an error when a container exited with a
+// non-zero code or when its health check fails
+func dockerContainerError(container types.Container) error {
+	switch container.State {
+	case "exited", "dead":
+		m := dockerExitCodeRegexp.FindStringSubmatch(container.Status)
+		if m == nil && container.State == "exited" {
+			return nil
+		}
+		if m == nil || m[1] != "0" {
+			return errors.Errorf("the container is %s (%s)", container.State, container.Status)
+		}
+	case "running":
+		if strings.Contains(container.Status, "(unhealthy)") {
+			return errors.Errorf("the container is unhealthy (%s)", container.Status)
+		}
+	}
+	return nil
+}
+
+func (l *Local) RelationshipsFromDocker() Relationships {
+	project := l.getComposeProjectName()
+	if project == "" {
+		return nil
+	}
+
+	client, err := newDockerClient()
+	if err != nil {
+		if l.Debug {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		}
+		return nil
+	}
+	defer client.Close()
+
+	containers, err := l.composeContainers(client, project, false)
+	if err != nil {
+		if docker.IsErrConnectionFailed(err) {
+			terminal.Logger.Warn().Msg(err.Error())
+		} else if l.Debug {
+			fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		}
+		return nil
+	}
+
+	relationships := Relationships{}
+	for _, container := range containers {
 		for suffix, relationship := range l.dockerServiceToRelationship(client, container) {
 			// get the service name
 			name, ok := container.Labels["com.symfony.server.service-prefix"]
@@ -183,24 +339,30 @@ func (l *Local) dockerServiceToRelationship(client *docker.Client, container typ
 		}
 	}
 
-	host := os.Getenv("DOCKER_HOST")
-	if host == "" || strings.HasPrefix(host, "unix://") {
-		host = "127.0.0.1"
-	} else {
-		u, err := url.Parse(host)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "  ERROR: unable to parse DOCKER_HOST \"%s\", falling back to 127.0.0.1: %s\n", host, err)
-			host = "127.0.0.1"
-		} else {
-			host = u.Hostname()
-		}
-	}
+	host := dockerHost()
 
 	sort.Sort(exposedPorts)
-	for _, p := range exposedPorts {
+	candidates := exposedPorts
+	serviceType := l.dockerServiceType(container)
+	if serviceType != "" {
+		// use the default port of the service, or the first exposed one
+		// when the service listens on a custom port
+		candidates = exposedPorts[:1]
+		for i, p := range exposedPorts {
+			if p.PrivatePort == dockerServiceDefaultPorts[serviceType] {
+				candidates = exposedPorts[i : i+1]
+				break
+			}
+		}
+	}
+	for _, p := range candidates {
+		kind := serviceType
+		if kind == "" {
+			kind = dockerPortServiceTypes[p.PrivatePort]
+		}
 		rels := make(map[string]map[string]interface{})
-		if p.PrivatePort == 1025 {
-			// recommended image: schickling/mailcatcher
+		if kind == "mailer" {
+			// recommended images: axllent/mailpit, schickling/mailcatcher
 			for _, pw := range exposedPorts {
 				if pw.PrivatePort == 1080 || pw.PrivatePort == 8025 {
 					rels["-web"] = map[string]interface{}{
@@ -210,17 +372,18 @@ func (l *Local) dockerServiceToRelationship(client *docker.Client, container typ
 						"rel":    "mailer",
 						"scheme": "http",
 					}
-					rels[""] = map[string]interface{}{
-						"host":   host,
-						"ip":     host,
-						"port":   formatDockerPort(p.PublicPort),
-						"rel":    "mailer",
-						"scheme": "smtp",
-					}
-					return rels
+					break
 				}
 			}
-		} else if p.PrivatePort == 25 {
+			rels[""] = map[string]interface{}{
+				"host":   host,
+				"ip":     host,
+				"port":   formatDockerPort(p.PublicPort),
+				"rel":    "mailer",
+				"scheme": "smtp",
+			}
+			return rels
+		} else if kind == "maildev" {
 			// recommended image: djfarrelly/maildev
 			for _, pw := range exposedPorts {
 				if pw.PrivatePort == 80 {
@@ -241,7 +404,7 @@ func (l *Local) dockerServiceToRelationship(client *docker.Client, container typ
 					return rels
 				}
 			}
-		} else if p.PrivatePort == 8707 || p.PrivatePort == 8307 {
+		} else if kind == "blackfire" {
 			// Blackfire
 			rels[""] = map[string]interface{}{
 				"host":   host,
@@ -251,7 +414,7 @@ func (l *Local) dockerServiceToRelationship(client *docker.Client, container typ
 				"scheme": "tcp",
 			}
 			return rels
-		} else if p.PrivatePort == 3306 {
+		} else if kind == "mysql" {
 			username := ""
 			password := ""
 			path := ""
@@ -288,7 +451,7 @@ func (l *Local) dockerServiceToRelationship(client *docker.Client, container typ
 				"scheme": "mysql",
 			}
 			return rels
-		} else if p.PrivatePort == 5432 {
+		} else if kind == "postgresql" {
 			username := ""
 			password := ""
 			path := ""
@@ -318,7 +481,7 @@ func (l *Local) dockerServiceToRelationship(client *docker.Client, container typ
 				"scheme": "pgsql",
 			}
 			return rels
-		} else if p.PrivatePort == 6379 {
+		} else if kind == "redis" {
 			rels[""] = map[string]interface{}{
 				"host":   host,
 				"ip":     host,
@@ -327,7 +490,7 @@ func (l *Local) dockerServiceToRelationship(client *docker.Client, container typ
 				"scheme": "redis",
 			}
 			return rels
-		} else if p.PrivatePort == 11211 {
+		} else if kind == "memcached" {
 			rels[""] = map[string]interface{}{
 				"host":   host,
 				"ip":     host,
@@ -336,7 +499,7 @@ func (l *Local) dockerServiceToRelationship(client *docker.Client, container typ
 				"scheme": "memcached",
 			}
 			return rels
-		} else if p.PrivatePort == 5672 {
+		} else if kind == "rabbitmq" {
 			username := "guest"
 			password := "guest"
 			for _, env := range c.Config.Env {
@@ -370,7 +533,7 @@ func (l *Local) dockerServiceToRelationship(client *docker.Client, container typ
 				}
 			}
 			return rels
-		} else if p.PrivatePort == 9200 {
+		} else if kind == "elasticsearch" {
 			rels[""] = map[string]interface{}{
 				"host":   host,
 				"ip":     host,
@@ -380,7 +543,7 @@ func (l *Local) dockerServiceToRelationship(client *docker.Client, container typ
 				"scheme": "http",
 			}
 			return rels
-		} else if p.PrivatePort == 27017 {
+		} else if kind == "mongodb" {
 			path := ""
 			for _, env := range c.Config.Env {
 				// that's our local convention
@@ -399,7 +562,7 @@ func (l *Local) dockerServiceToRelationship(client *docker.Client, container typ
 				"scheme":   "mongodb",
 			}
 			return rels
-		} else if p.PrivatePort == 9092 {
+		} else if kind == "kafka" {
 			rels[""] = map[string]interface{}{
 				"host":   host,
 				"ip":     host,
@@ -408,7 +571,7 @@ func (l *Local) dockerServiceToRelationship(client *docker.Client, container typ
 				"scheme": "kafka",
 			}
 			return rels
-		} else if p.PrivatePort == 80 && container.Image == "dunglas/mercure" {
+		} else if kind == "mercure" {
 			rels[""] = map[string]interface{}{
 				"host":   host,
 				"ip":     host,
@@ -417,6 +580,120 @@ func (l *Local) dockerServiceToRelationship(client *docker.Client, container typ
 				"scheme": "http",
 			}
 			return rels
+		} else if kind == "opensearch" {
+			rels[""] = map[string]interface{}{
+				"host":   host,
+				"ip":     host,
+				"port":   formatDockerPort(p.PublicPort),
+				"path":   "/",
+				"rel":    "opensearch",
+				"scheme": "http",
+			}
+			disabled := false
+			password := "admin"
+			for _, env := range c.Config.Env {
+				if strings.HasPrefix(env, "DISABLE_SECURITY_PLUGIN") {
+					disabled, _ = strconv.ParseBool(getEnvValue(env, "DISABLE_SECURITY_PLUGIN"))
+				} else if strings.HasPrefix(env, "OPENSEARCH_INITIAL_ADMIN_PASSWORD") {
+					password = getEnvValue(env, "OPENSEARCH_INITIAL_ADMIN_PASSWORD")
+				}
+			}
+			if !disabled {
+				// the security plugin is enabled by default
+				rels[""]["scheme"] = "https"
+				rels[""]["username"] = "admin"
+				rels[""]["password"] = password
+			}
+			return rels
+		} else if kind == "meilisearch" || kind == "typesense" {
+			key := ""
+			for _, env := range c.Config.Env {
+				if strings.HasPrefix(env, "MEILI_MASTER_KEY") {
+					key = getEnvValue(env, "MEILI_MASTER_KEY")
+				} else if strings.HasPrefix(env, "TYPESENSE_API_KEY") {
+					key = getEnvValue(env, "TYPESENSE_API_KEY")
+				}
+			}
+			rels[""] = map[string]interface{}{
+				"host":    host,
+				"ip":      host,
+				"port":    formatDockerPort(p.PublicPort),
+				"api_key": key,
+				"rel":     kind,
+				"scheme":  "http",
+			}
+			return rels
+		} else if kind == "clickhouse" {
+			username := "default"
+			password := ""
+			path := "default"
+			for _, env := range c.Config.Env {
+				if strings.HasPrefix(env, "CLICKHOUSE_USER") {
+					username = getEnvValue(env, "CLICKHOUSE_USER")
+				} else if strings.HasPrefix(env, "CLICKHOUSE_PASSWORD") {
+					password = getEnvValue(env, "CLICKHOUSE_PASSWORD")
+				} else if strings.HasPrefix(env, "CLICKHOUSE_DB") {
+					path = getEnvValue(env, "CLICKHOUSE_DB")
+				}
+			}
+			rels[""] = map[string]interface{}{
+				"host":     host,
+				"ip":       host,
+				"port":     formatDockerPort(p.PublicPort),
+				"username": username,
+				"password": password,
+				"path":     path,
+				"rel":      "clickhouse",
+				"scheme":   "http",
+			}
+			return rels
+		} else if kind == "s3" {
+			// MinIO defaults, MINIO_ACCESS_KEY and MINIO_SECRET_KEY are deprecated
+			username := "minioadmin"
+			password := "minioadmin"
+			for _, env := range c.Config.Env {
+				if strings.HasPrefix(env, "MINIO_ROOT_USER") {
+					username = getEnvValue(env, "MINIO_ROOT_USER")
+				} else if strings.HasPrefix(env, "MINIO_ACCESS_KEY") {
+					username = getEnvValue(env, "MINIO_ACCESS_KEY")
+				} else if strings.HasPrefix(env, "MINIO_ROOT_PASSWORD") {
+					password = getEnvValue(env, "MINIO_ROOT_PASSWORD")
+				} else if strings.HasPrefix(env, "MINIO_SECRET_KEY") {
+					password = getEnvValue(env, "MINIO_SECRET_KEY")
+				}
+			}
+			rels[""] = map[string]interface{}{
+				"host":     host,
+				"ip":       host,
+				"port":     formatDockerPort(p.PublicPort),
+				"username": username,
+				"password": password,
+				"rel":      "s3",
+				"scheme":   "http",
+			}
+			// web console?
+			for _, pw := range exposedPorts {
+				if pw.PrivatePort == 9001 {
+					rels["-console"] = map[string]interface{}{
+						"host":   host,
+						"ip":     host,
+						"port":   formatDockerPort(pw.PublicPort),
+						"rel":    "s3",
+						"scheme": "http",
+					}
+					break
+				}
+			}
+			return rels
+		} else if kind == "varnish" {
+			rels[""] = map[string]interface{}{
+				"host":   host,
+				"ip":     host,
+				"port":   formatDockerPort(p.PublicPort),
+				"rel":    "varnish",
+				"scheme": "http",
+			}
+			return rels
 		}
 
 		if l.Debug {
@@ -435,6 +712,43 @@ func (l *Local) dockerServiceToRelationship(client *docker.Client, container typ
 	return nil
 }
 
+// dockerServiceType returns the type of service run by a container, as set by
+// the com.symfony.server.service-type label or guessed from its image
+func (l *Local) dockerServiceType(container types.Container) string {
+	if t, ok := container.Labels["com.symfony.server.service-type"]; ok {
+		t = strings.ToLower(t)
+		if alias, ok := dockerServiceTypeAliases[t]; ok {
+			t = alias
+		}
+		if l.Debug {
+			fmt.Fprintf(os.Stderr, "  service type \"%s\" set by label\n", t)
+		}
+		return t
+	}
+	if t, ok := dockerImageServiceTypes[normalizeDockerImageName(container.Image)]; ok {
+		if l.Debug {
+			fmt.Fprintf(os.Stderr, "  service type \"%s\" guessed from image\n", t)
+		}
+		return t
+	}
+	return ""
+}
+
+// normalizeDockerImageName strips the registry, the tag and the digest of an
+// image name ("docker.io/library/postgres:16-alpine" becomes "postgres")
+func normalizeDockerImageName(image string) string {
+	if i := strings.Index(image, "@"); i != -1 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i != -1 && !strings.Contains(image[i:], "/") {
+		image = image[:i]
+	}
+	if parts := strings.SplitN(image, "/", 2); len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		image = parts[1]
+	}
+	return strings.TrimPrefix(image, "library/")
+}
+
 func formatDockerPort(port uint16) string {
 	return strconv.FormatInt(int64(port), 10)
 }
@@ -448,11 +762,6 @@ func getEnvValue(env string, key string) string {
 }
 
 func (l *Local) getComposeProjectName() string {
-	// https://docs.docker.com/compose/reference/envvars/#compose_project_name
-	if project := os.Getenv("COMPOSE_PROJECT_NAME"); project != "" {
-		return project
-	}
-
 	composeDir := l.getComposeDir()
 	if composeDir == "" {
 		if l.Debug {
@@ -461,40 +770,41 @@ func (l *Local) getComposeProjectName() string {
 		return ""
 	}
 
-	// COMPOSE_PROJECT_NAME can be set in a .env file
-	if _, err := os.Stat(filepath.Join(composeDir, ".env")); err == nil {
-		if contents, err := ioutil.ReadFile(filepath.Join(composeDir, ".env")); err == nil {
-			for _, line := range bytes.Split(contents, []byte("\n")) {
-				if bytes.HasPrefix(line, []byte("COMPOSE_PROJECT_NAME=")) {
-					return string(line[len("COMPOSE_PROJECT_NAME="):])
-				}
-			}
+	// the project name is resolved from COMPOSE_PROJECT_NAME (which can be
+	// set in a .env file), the top-level "name" key, or the directory name
+	// https://docs.docker.com/compose/reference/envvars/#compose_project_name
+	name, err := composeProjectName(composeDir)
+	if err != nil {
+		if l.Debug {
+			fmt.Fprintf(os.Stderr, "ERROR: unable to load the Docker Compose project: %s\n", err)
+		}
+		if name := composeEnv(composeDir)["COMPOSE_PROJECT_NAME"]; name != "" {
+			return name
 		}
+		return filepath.Base(composeDir)
 	}
 
-	return filepath.Base(composeDir)
+	return name
 }
 
 func (l *Local) getComposeDir() string {
 	// https://docs.docker.com/compose/reference/envvars/#compose_file
-	if os.Getenv("COMPOSE_FILE") != "" {
+	if composeEnv(l.Dir)["COMPOSE_FILE"] != "" {
 		return l.Dir
 	}
 
-	// look for the first dir up with a docker-composer.ya?ml file (in case of a multi-project)
+	// look for the first dir up with a compose file (in case of a multi-project)
 	dir := l.Dir
 	for {
-		if _, err := os.Stat(filepath.Join(dir, "docker-compose.yaml")); err == nil {
-			return dir
-		}
-		// both .yml and .yaml are supported by Docker composer
-		if _, err := os.Stat(filepath.Join(dir, "docker-compose.yml")); err == nil {
-			return dir
+		for _, name := range compose.DefaultFileNames {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return dir
+			}
 		}
 		upDir := filepath.Dir(dir)
 		if upDir == dir || upDir == "." {
 			if l.Debug {
-				fmt.Fprintln(os.Stderr, "ERROR: unable to find a docker-compose.yaml or docker-compose.yml for the current directory")
+				fmt.Fprintln(os.Stderr, "ERROR: unable to find a compose.yaml or docker-compose.yaml file for the current directory")
 			}
 			return ""
 		}
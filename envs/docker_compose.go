@@ -0,0 +1,252 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package envs
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	compose "github.com/compose-spec/compose-go/cli"
+	"github.com/compose-spec/compose-go/types"
+	"github.com/pkg/errors"
+)
+
+// DockerComposeBin returns the command to use to run Docker Compose
+func DockerComposeBin() []string {
+	if path, err := exec.LookPath("docker-compose"); err == nil {
+		return []string{path}
+	}
+	return []string{"docker", "compose"}
+}
+
+// LoadComposeProject loads the Docker Compose project of a directory the way
+// Docker Compose does: compose.yaml and override files, COMPOSE_FILE, the
+// .env file for interpolation, and the profiles enabled via COMPOSE_PROFILES
+func LoadComposeProject(dir string) (*types.Project, error) {
+	options, err := compose.NewProjectOptions(nil, compose.WithWorkingDirectory(dir), compose.WithOsEnv, compose.WithDotEnv)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if files := options.Environment["COMPOSE_FILE"]; files != "" {
+		// relative paths are resolved from the project directory, not from
+		// the current working directory
+		sep := options.Environment["COMPOSE_PATH_SEPARATOR"]
+		if sep == "" {
+			sep = string(os.PathListSeparator)
+		}
+		for _, file := range strings.Split(files, sep) {
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(options.WorkingDir, file)
+			}
+			options.ConfigPaths = append(options.ConfigPaths, file)
+		}
+	} else if err := compose.WithDefaultConfigPath(options); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	project, err := compose.ProjectFromOptions(options)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var profiles []string
+	for _, profile := range strings.Split(options.Environment["COMPOSE_PROFILES"], ",") {
+		if profile = strings.TrimSpace(profile); profile != "" {
+			profiles = append(profiles, profile)
+		}
+	}
+	project.ApplyProfiles(profiles)
+	return project, nil
+}
+
+// composeCache avoids parsing the .env and Compose files again when they did
+// not change, as the Compose project is resolved for every PHP request
+var composeCache = struct {
+	sync.Mutex
+	envs  map[string]cachedCompose
+	names map[string]cachedCompose
+}{
+	envs:  map[string]cachedCompose{},
+	names: map[string]cachedCompose{},
+}
+
+type cachedCompose struct {
+	signature string
+	env       map[string]string
+	name      string
+	err       error
+}
+
+// composeSignature identifies the state of the process environment and of
+// the given files (modification time and size)
+func composeSignature(files ...string) string {
+	var b strings.Builder
+	b.WriteString(strings.Join(os.Environ(), "\x00"))
+	for _, file := range files {
+		b.WriteString("\x00" + file)
+		if fi, err := os.Stat(file); err == nil {
+			fmt.Fprintf(&b, ":%d:%d", fi.ModTime().UnixNano(), fi.Size())
+		}
+	}
+	return b.String()
+}
+
+// composeEnv returns the environment used by Docker Compose in a directory:
+// the .env file values, overridden by the process environment
+func composeEnv(dir string) map[string]string {
+	signature := composeSignature(filepath.Join(dir, ".env"))
+	composeCache.Lock()
+	defer composeCache.Unlock()
+	if cached, ok := composeCache.envs[dir]; ok && cached.signature == signature {
+		return cached.env
+	}
+
+	options, err := compose.NewProjectOptions(nil, compose.WithWorkingDirectory(dir), compose.WithOsEnv, compose.WithDotEnv)
+	if err != nil {
+		// an invalid .env file must not hide the process environment
+		options, _ = compose.NewProjectOptions(nil, compose.WithOsEnv)
+	}
+	composeCache.envs[dir] = cachedCompose{signature: signature, env: options.Environment}
+	return options.Environment
+}
+
+// composeProjectName returns the name of the Docker Compose project of a
+// directory, see LoadComposeProject
+func composeProjectName(dir string) (string, error) {
+	files := []string{filepath.Join(dir, ".env")}
+	for _, name := range compose.DefaultFileNames {
+		files = append(files, filepath.Join(dir, name))
+	}
+	for _, name := range compose.DefaultOverrideFileNames {
+		files = append(files, filepath.Join(dir, name))
+	}
+	env := composeEnv(dir)
+	if paths := env["COMPOSE_FILE"]; paths != "" {
+		sep := env["COMPOSE_PATH_SEPARATOR"]
+		if sep == "" {
+			sep = string(os.PathListSeparator)
+		}
+		for _, file := range strings.Split(paths, sep) {
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(dir, file)
+			}
+			files = append(files, file)
+		}
+	}
+	signature := composeSignature(files...)
+
+	composeCache.Lock()
+	defer composeCache.Unlock()
+	if cached, ok := composeCache.names[dir]; ok && cached.signature == signature {
+		return cached.name, cached.err
+	}
+	name := ""
+	project, err := LoadComposeProject(dir)
+	if err == nil {
+		name = project.Name
+	}
+	composeCache.names[dir] = cachedCompose{signature: signature, name: name, err: err}
+	return name, err
+}
+
+// ComposeDir returns the directory of the Docker Compose project
+func (l *Local) ComposeDir() string {
+	return l.getComposeDir()
+}
+
+// ComposeCommand returns a Docker Compose command for the project
+func (l *Local) ComposeCommand(args ...string) (*exec.Cmd, error) {
+	dir := l.getComposeDir()
+	if dir == "" {
+		return nil, errors.New("unable to find a Docker Compose file for the project")
+	}
+	bin := DockerComposeBin()
+	cmd := exec.Command(bin[0], append(bin[1:], args...)...)
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd, nil
+}
+
+// WaitForDockerServices waits for the published ports of the project
+// containers to accept connections and for their health checks to pass
+func (l *Local) WaitForDockerServices(timeout time.Duration) error {
+	project := l.getComposeProjectName()
+	if project == "" {
+		return errors.New("unable to find a Docker Compose file for the project")
+	}
+	client, err := newDockerClient()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer client.Close()
+
+	host := dockerHost()
+	deadline := time.Now().Add(timeout)
+	for {
+		containers, err := l.composeContainers(client, project, true)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		var pending []string
+		for _, container := range containers {
+			name := container.Labels["com.docker.compose.service"]
+			if err := dockerContainerError(container); err != nil {
+				return errors.Wrapf(err, "Docker service \"%s\" failed", name)
+			}
+			if container.State == "exited" {
+				// one-off services (migrations, ...) exiting successfully
+				continue
+			}
+			if container.State != "running" || strings.Contains(container.Status, "health: starting") {
+				pending = append(pending, name)
+				continue
+			}
+			for _, port := range container.Ports {
+				if port.PublicPort == 0 || port.Type != "tcp" {
+					continue
+				}
+				conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(int(port.PublicPort))), time.Second)
+				if err != nil {
+					pending = append(pending, fmt.Sprintf("%s (port %d)", name, port.PublicPort))
+					break
+				}
+				conn.Close()
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("timed out waiting for Docker services: %s", strings.Join(pending, ", "))
+		}
+		if l.Debug {
+			fmt.Fprintf(os.Stderr, "waiting for Docker services: %s\n", strings.Join(pending, ", "))
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
@@ -20,6 +20,11 @@
 package envs
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/api/types"
 	. "gopkg.in/check.v1"
 )
 
@@ -44,3 +49,100 @@ func (s *DockerSuite) TestNormalizeDockerComposeProjectName(c *C) {
 		c.Check(normalizeDockerComposeProjectNameLegacy(testCase.ProjectName), Equals, testCase.ExpectedLegacy)
 	}
 }
+
+func unsetComposeEnv() func() {
+	var restore []func()
+	for _, name := range []string{"COMPOSE_FILE", "COMPOSE_PROJECT_NAME", "COMPOSE_PROFILES"} {
+		if value, ok := os.LookupEnv(name); ok {
+			name := name
+			restore = append(restore, func() { os.Setenv(name, value) })
+		}
+		os.Unsetenv(name)
+	}
+	return func() {
+		for _, r := range restore {
+			r()
+		}
+	}
+}
+
+func (s *DockerSuite) TestComposeProject(c *C) {
+	defer unsetComposeEnv()()
+
+	l := &Local{Dir: "testdata/compose/named/sub"}
+	c.Assert(l.getComposeDir(), Equals, "testdata/compose/named")
+	c.Assert(l.getComposeProjectName(), Equals, "my-app")
+	project, err := LoadComposeProject("testdata/compose/named")
+	c.Assert(err, IsNil)
+	c.Assert(project.ServiceNames(), DeepEquals, []string{"database"})
+	c.Assert(project.Services[0].Image, Equals, "postgres:15-alpine")
+	c.Assert(project.Services[0].Environment["POSTGRES_PASSWORD"], NotNil)
+
+	os.Setenv("COMPOSE_PROFILES", "mail")
+	project, err = LoadComposeProject("testdata/compose/named")
+	c.Assert(err, IsNil)
+	c.Assert(len(project.Services), Equals, 2)
+	os.Unsetenv("COMPOSE_PROFILES")
+
+	l = &Local{Dir: "testdata/compose/env_file"}
+	c.Assert(l.getComposeDir(), Equals, "testdata/compose/env_file")
+	c.Assert(l.getComposeProjectName(), Equals, "from-dotenv")
+	project, err = LoadComposeProject("testdata/compose/env_file")
+	c.Assert(err, IsNil)
+	c.Assert(project.Services[0].Image, Equals, "redis:7")
+	c.Assert(len(project.Services[0].Ports), Equals, 1)
+}
+
+func (s *DockerSuite) TestComposeProjectNameIsCached(c *C) {
+	defer unsetComposeEnv()()
+
+	dir := c.MkDir()
+	file := filepath.Join(dir, "compose.yaml")
+	c.Assert(ioutil.WriteFile(file, []byte("name: first\nservices:\n  redis:\n    image: redis\n"), 0644), IsNil)
+	l := &Local{Dir: dir}
+	c.Assert(l.getComposeProjectName(), Equals, "first")
+	c.Assert(l.getComposeProjectName(), Equals, "first")
+
+	c.Assert(ioutil.WriteFile(file, []byte("name: second-name\nservices:\n  redis:\n    image: redis\n"), 0644), IsNil)
+	c.Assert(l.getComposeProjectName(), Equals, "second-name")
+}
+
+func (s *DockerSuite) TestDockerServiceType(c *C) {
+	l := &Local{}
+	for _, testCase := range []struct {
+		Image    string
+		Labels   map[string]string
+		Expected string
+	}{
+		{"postgres:16-alpine", nil, "postgresql"},
+		{"docker.io/library/postgres:16", nil, "postgresql"},
+		{"docker.elastic.co/elasticsearch/elasticsearch:8.11.0", nil, "elasticsearch"},
+		{"quay.io/minio/minio@sha256:0123", nil, "s3"},
+		{"localhost:5000/getmeili/meilisearch:v1.5", nil, "meilisearch"},
+		{"axllent/mailpit", nil, "mailer"},
+		{"my/postgres:16", nil, ""},
+		{"my/postgres:16", map[string]string{"com.symfony.server.service-type": "Postgres"}, "postgresql"},
+		{"mysql:8", map[string]string{"com.symfony.server.service-type": "simple"}, "simple"},
+	} {
+		container := types.Container{Image: testCase.Image, Labels: testCase.Labels}
+		c.Check(l.dockerServiceType(container), Equals, testCase.Expected, Commentf("image %s", testCase.Image))
+	}
+}
+
+func (s *DockerSuite) TestDockerContainerError(c *C) {
+	for _, testCase := range []struct {
+		State, Status string
+		Failed        bool
+	}{
+		{"running", "Up 2 seconds", false},
+		{"running", "Up 2 seconds (health: starting)", false},
+		{"running", "Up 2 minutes (healthy)", false},
+		{"running", "Up 2 minutes (unhealthy)", true},
+		{"exited", "Exited (0) 3 seconds ago", false},
+		{"exited", "Exited (1) 3 seconds ago", true},
+		{"dead", "Dead", true},
+	} {
+		err := dockerContainerError(types.Container{State: testCase.State, Status: testCase.Status})
+		c.Check(err != nil, Equals, testCase.Failed, Commentf("status %s", testCase.Status))
+	}
+}
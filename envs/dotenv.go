@@ -50,6 +50,14 @@ func LoadDotEnv(vars map[string]string, scriptDir string) map[string]string {
 	return vars
 }
 
+// AppEnv returns the Symfony environment (APP_ENV) used locally by the project
+func AppEnv(projectDir string) string {
+	if env := LoadDotEnv(map[string]string{}, projectDir)["APP_ENV"]; env != "" {
+		return env
+	}
+	return "dev"
+}
+
 // algorithm is here: https://github.com/symfony/recipes/blob/master/symfony/framework-bundle/3.3/config/bootstrap.php
 func lookupDotEnv(dir string) map[string]string {
 	var err error
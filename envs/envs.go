@@ -210,15 +210,47 @@ func extractRelationshipsEnvs(env Environment) Envs {
 				values[fmt.Sprintf("%sPORT", prefix)] = formatInt(endpoint["port"])
 				values[fmt.Sprintf("%sNAME", prefix)] = endpoint["path"].(string)
 				values[fmt.Sprintf("%sDATABASE", prefix)] = endpoint["path"].(string)
-			} else if rel == "elasticsearch" {
+			} else if rel == "elasticsearch" || rel == "opensearch" {
 				path, hasPath := endpoint["path"]
 				if !hasPath || path == nil {
 					path = ""
 				}
-				values[fmt.Sprintf("%sURL", prefix)] = fmt.Sprintf("%s://%s:%s%s", endpoint["scheme"].(string), endpoint["host"].(string), formatInt(endpoint["port"]), path)
+				credentials := ""
+				if username, ok := endpoint["username"].(string); ok && username != "" {
+					credentials = fmt.Sprintf("%s:%s@", username, endpoint["password"].(string))
+					values[fmt.Sprintf("%sUSERNAME", prefix)] = username
+					values[fmt.Sprintf("%sPASSWORD", prefix)] = endpoint["password"].(string)
+				}
+				values[fmt.Sprintf("%sURL", prefix)] = fmt.Sprintf("%s://%s%s:%s%s", endpoint["scheme"].(string), credentials, endpoint["host"].(string), formatInt(endpoint["port"]), path)
+				values[fmt.Sprintf("%sHOST", prefix)] = endpoint["host"].(string)
+				values[fmt.Sprintf("%sPORT", prefix)] = formatInt(endpoint["port"])
+				values[fmt.Sprintf("%sSCHEME", prefix)] = endpoint["scheme"].(string)
+			} else if rel == "meilisearch" || rel == "typesense" {
+				values[fmt.Sprintf("%sURL", prefix)] = formatServer(endpoint)
+				values[fmt.Sprintf("%sHOST", prefix)] = endpoint["host"].(string)
+				values[fmt.Sprintf("%sPORT", prefix)] = formatInt(endpoint["port"])
+				values[fmt.Sprintf("%sSCHEME", prefix)] = endpoint["scheme"].(string)
+				values[fmt.Sprintf("%sAPI_KEY", prefix)] = endpoint["api_key"].(string)
+			} else if rel == "clickhouse" {
+				values[fmt.Sprintf("%sURL", prefix)] = fmt.Sprintf("%s://%s:%s@%s:%s/?database=%s", endpoint["scheme"].(string), endpoint["username"].(string), endpoint["password"].(string), endpoint["host"].(string), formatInt(endpoint["port"]), endpoint["path"].(string))
+				values[fmt.Sprintf("%sHOST", prefix)] = endpoint["host"].(string)
+				values[fmt.Sprintf("%sPORT", prefix)] = formatInt(endpoint["port"])
+				values[fmt.Sprintf("%sSCHEME", prefix)] = endpoint["scheme"].(string)
+				values[fmt.Sprintf("%sUSER", prefix)] = endpoint["username"].(string)
+				values[fmt.Sprintf("%sUSERNAME", prefix)] = endpoint["username"].(string)
+				values[fmt.Sprintf("%sPASSWORD", prefix)] = endpoint["password"].(string)
+				values[fmt.Sprintf("%sNAME", prefix)] = endpoint["path"].(string)
+				values[fmt.Sprintf("%sDATABASE", prefix)] = endpoint["path"].(string)
+			} else if rel == "s3" && endpoint["username"] != nil {
+				// S3-compatible storage, the variables follow the AWS SDK conventions
+				values[fmt.Sprintf("%sURL", prefix)] = formatServer(endpoint)
+				values[fmt.Sprintf("%sENDPOINT", prefix)] = formatServer(endpoint)
 				values[fmt.Sprintf("%sHOST", prefix)] = endpoint["host"].(string)
 				values[fmt.Sprintf("%sPORT", prefix)] = formatInt(endpoint["port"])
 				values[fmt.Sprintf("%sSCHEME", prefix)] = endpoint["scheme"].(string)
+				values[fmt.Sprintf("%sACCESS_KEY_ID", prefix)] = endpoint["username"].(string)
+				values[fmt.Sprintf("%sSECRET_ACCESS_KEY", prefix)] = endpoint["password"].(string)
+				values[fmt.Sprintf("%sREGION", prefix)] = "us-east-1"
 			} else if scheme == "mongodb" {
 				if !isMaster(endpoint) {
 					continue
@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package humanlog
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// nginx/Apache combined (and common) log format
+// 127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"
+var combinedLogLineRegexp = regexp.MustCompile(`^(\S+) \S+ (\S+) \[([^\]]+)\] "(\S+) (\S+)(?: ([^"]*))?" (\d{3}) (\d+|-)(?: "([^"]*)" "([^"]*)")?`)
+
+func convertCombinedLog(in []byte) (*line, error) {
+	matches := combinedLogLineRegexp.FindSubmatch(in)
+	if matches == nil {
+		return nil, nil
+	}
+	t, err := time.Parse("02/Jan/2006:15:04:05 -0700", string(matches[3]))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	status, _ := strconv.Atoi(string(matches[7]))
+	line := &line{
+		time:    t,
+		source:  "access",
+		level:   "info",
+		message: string(matches[5]),
+		fields: map[string]string{
+			"ip":     convertAnyVal(string(matches[1])),
+			"method": convertAnyVal(string(matches[4])),
+			"status": strconv.Itoa(status),
+		},
+	}
+	if status >= 500 {
+		line.level = "error"
+	} else if status >= 400 {
+		line.level = "warning"
+	}
+	if user := string(matches[2]); user != "-" {
+		line.fields["user"] = convertAnyVal(user)
+	}
+	if size := string(matches[8]); size != "-" {
+		line.fields["size"] = size
+	}
+	if referer := string(matches[9]); referer != "" && referer != "-" {
+		line.fields["referer"] = convertAnyVal(referer)
+	}
+	if agent := string(matches[10]); agent != "" && agent != "-" {
+		line.fields["user_agent"] = convertAnyVal(agent)
+	}
+	return line, nil
+}
@@ -0,0 +1,260 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// number of stack frames displayed for an exception
+const exceptionFrames = 5
+
+// exception is an exception serialized by Monolog in the log context
+type exception struct {
+	class    string
+	message  string
+	code     string
+	file     string
+	line     int
+	trace    []string
+	previous *exception
+}
+
+// Exception is the exception of a log line as exposed by Parse
+type Exception struct {
+	Class    string     `json:"class"`
+	Message  string     `json:"message"`
+	Code     string     `json:"code,omitempty"`
+	File     string     `json:"file,omitempty"`
+	Line     int        `json:"line,omitempty"`
+	Previous *Exception `json:"previous,omitempty"`
+}
+
+func (e *exception) export() *Exception {
+	if e == nil {
+		return nil
+	}
+	return &Exception{
+		Class:    e.class,
+		Message:  e.message,
+		Code:     e.code,
+		File:     e.file,
+		Line:     e.line,
+		Previous: e.previous.export(),
+	}
+}
+
+// Class(code: 0): message at /app/src/Foo.php:12
+var exceptionStringRegexp = regexp.MustCompile(`(?s)^([\w\\]+)\(code: ([^)]*)\): (.*) at (.+?):(\d+)$`)
+var exceptionStartRegexp = regexp.MustCompile(`[\w\\]+\(code: [^)]*\): `)
+var fileLineRegexp = regexp.MustCompile(`^(.+?):(\d+)$`)
+
+// ideLinkFormats are the shortcuts supported by SYMFONY_IDE
+var ideLinkFormats = map[string]string{
+	"textmate": "txmt://open?url=file://%f&line=%l",
+	"macvim":   "mvim://open?url=file://%f&line=%l",
+	"emacs":    "emacs://open?url=file://%f&line=%l",
+	"sublime":  "subl://open?url=file://%f&line=%l",
+	"phpstorm": "phpstorm://open?file=%f&line=%l",
+	"atom":     "atom://core/open/file?filename=%f&line=%l",
+	"vscode":   "vscode://file/%f:%l",
+}
+
+// extractException finds and parses the exception of a log line context
+func extractException(in []byte) *exception {
+	idx := bytes.Index(in, []byte(`"exception":`))
+	if idx == -1 {
+		return nil
+	}
+	var raw json.RawMessage
+	if err := json.NewDecoder(bytes.NewReader(in[idx+len(`"exception":`):])).Decode(&raw); err != nil {
+		return nil
+	}
+	return parseException(raw)
+}
+
+func parseException(raw json.RawMessage) *exception {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return parseExceptionString(s)
+	}
+	var o map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &o); err != nil {
+		return nil
+	}
+	e := &exception{}
+	for _, key := range []string{"class", "type"} {
+		if v, ok := o[key]; ok {
+			json.Unmarshal(v, &e.class)
+		}
+	}
+	if e.class == "" {
+		return nil
+	}
+	json.Unmarshal(o["message"], &e.message)
+	if code, ok := o["code"]; ok {
+		e.code = strings.Trim(string(code), `"`)
+	}
+	json.Unmarshal(o["file"], &e.file)
+	if v, ok := o["line"]; ok {
+		json.Unmarshal(v, &e.line)
+	} else if m := fileLineRegexp.FindStringSubmatch(e.file); m != nil {
+		e.file = m[1]
+		e.line, _ = strconv.Atoi(m[2])
+	}
+	// Monolog serializes the trace as a list, Symfony as a string
+	if err := json.Unmarshal(o["trace"], &e.trace); err != nil {
+		var trace string
+		if err := json.Unmarshal(o["trace"], &trace); err == nil {
+			e.trace = splitTrace(trace)
+		}
+	}
+	if previous, ok := o["previous"]; ok {
+		e.previous = parseException(previous)
+	}
+	return e
+}
+
+// parseExceptionString parses the Monolog LineFormatter representation:
+// [object] (Class(code: 0): message at /file:12, Previous\Class(code: 0): ...)
+// optionally followed by [stacktrace] and [previous exception] blocks
+func parseExceptionString(s string) *exception {
+	s = strings.TrimPrefix(s, "[object] ")
+	var trace string
+	if idx := strings.Index(s, "\n[stacktrace]\n"); idx != -1 {
+		trace = s[idx+len("\n[stacktrace]\n"):]
+		s = s[:idx]
+		// the traces of the previous exceptions are not displayed
+		if idx := strings.Index(trace, "\n[previous exception]"); idx != -1 {
+			trace = trace[:idx]
+		}
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "("), ")")
+
+	starts := exceptionStartRegexp.FindAllStringIndex(s, -1)
+	var first, last *exception
+	for i, start := range starts {
+		end := len(s)
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+		m := exceptionStringRegexp.FindStringSubmatch(strings.TrimSuffix(s[start[0]:end], ", "))
+		if m == nil {
+			continue
+		}
+		e := &exception{class: m[1], code: m[2], message: m[3], file: m[4]}
+		e.line, _ = strconv.Atoi(m[5])
+		if first == nil {
+			first = e
+		} else {
+			last.previous = e
+		}
+		last = e
+	}
+	if first != nil {
+		first.trace = splitTrace(trace)
+	}
+	return first
+}
+
+func splitTrace(trace string) []string {
+	frames := []string{}
+	for _, frame := range strings.Split(trace, "\n") {
+		if frame = strings.TrimSpace(frame); frame != "" && frame != `"}` {
+			frames = append(frames, frame)
+		}
+	}
+	return frames
+}
+
+func (h *Handler) renderException(buf *bytes.Buffer, e *exception) {
+	for depth := 0; e != nil; depth++ {
+		buf.WriteString("\n    ")
+		if depth > 0 {
+			buf.WriteString("Previous: ")
+		}
+		buf.WriteString("<error>" + e.class + "</>")
+		if e.code != "" && e.code != "0" {
+			buf.WriteString(" (code " + e.code + ")")
+		}
+		buf.WriteString(": " + e.message)
+		if e.file != "" {
+			buf.WriteString("\n      at " + h.fileLink(e.file, e.line))
+		}
+		frames := e.trace
+		if len(frames) > exceptionFrames {
+			frames = frames[:exceptionFrames]
+		}
+		for _, frame := range frames {
+			buf.WriteString("\n      " + h.frameLink(frame))
+		}
+		if hidden := len(e.trace) - len(frames); hidden > 0 {
+			fmt.Fprintf(buf, "\n      <comment>... %d more frames</>", hidden)
+		}
+		e = e.previous
+	}
+}
+
+// #0 /app/src/Foo.php(12): Foo->bar() or /app/src/Foo.php:12
+var framePHPRegexp = regexp.MustCompile(`^(#\d+ )(/[^(]+)\((\d+)\)(.*)$`)
+
+func (h *Handler) frameLink(frame string) string {
+	if m := framePHPRegexp.FindStringSubmatch(frame); m != nil {
+		line, _ := strconv.Atoi(m[3])
+		return m[1] + h.fileLink(m[2], line) + strings.TrimPrefix(m[4], ":")
+	}
+	if m := fileLineRegexp.FindStringSubmatch(frame); m != nil && filepath.IsAbs(m[1]) {
+		line, _ := strconv.Atoi(m[2])
+		return h.fileLink(m[1], line)
+	}
+	return frame
+}
+
+// fileLink displays a path relative to the project, linked to the file in
+// the IDE configured via SYMFONY_IDE (or to the file itself)
+func (h *Handler) fileLink(file string, line int) string {
+	display := file
+	if h.opts.ProjectDir != "" {
+		if rel, err := filepath.Rel(h.opts.ProjectDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			display = rel
+		}
+	}
+	display = fmt.Sprintf("%s:%d", display, line)
+
+	format := h.opts.FileLinkFormat
+	if format == "" {
+		format = os.Getenv("SYMFONY_IDE")
+	}
+	if f, ok := ideLinkFormats[format]; ok {
+		format = f
+	}
+	if format == "" {
+		format = "file://%f"
+	}
+	link := strings.NewReplacer("%f", file, "%l", strconv.Itoa(line)).Replace(format)
+	return fmt.Sprintf("<href=%s>%s</>", link, display)
+}
@@ -0,0 +1,52 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package humanlog
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *HumanlogSuite) TestExtractException(c *C) {
+	e := extractException([]byte(`[2017-10-03 21:38:50] request.ERROR: Uncaught PHP Exception Symfony\Component\HttpKernel\Exception\NotFoundHttpException: "No route found for "GET /community"" at /app/vendor/symfony/http-kernel/EventListener/RouterListener.php line 125 {"exception":"[object] (Symfony\\Component\\HttpKernel\\Exception\\NotFoundHttpException(code: 0): No route found for \"GET /community\" at /app/vendor/symfony/http-kernel/EventListener/RouterListener.php:125, Symfony\\Component\\Routing\\Exception\\ResourceNotFoundException(code: 0):  at /app/var/cache/prod/srcProdProjectContainerUrlMatcher.php:104)"} []`))
+	c.Assert(e, NotNil)
+	c.Assert(e.class, Equals, `Symfony\Component\HttpKernel\Exception\NotFoundHttpException`)
+	c.Assert(e.message, Equals, `No route found for "GET /community"`)
+	c.Assert(e.file, Equals, "/app/vendor/symfony/http-kernel/EventListener/RouterListener.php")
+	c.Assert(e.line, Equals, 125)
+	c.Assert(e.previous, NotNil)
+	c.Assert(e.previous.class, Equals, `Symfony\Component\Routing\Exception\ResourceNotFoundException`)
+	c.Assert(e.previous.line, Equals, 104)
+
+	e = extractException([]byte(`{"message":"failed","exception":{"class":"RuntimeException","message":"boom","code":12,"file":"/app/src/Foo.php:7","trace":["/app/src/Bar.php:3"],"previous":{"class":"LogicException","message":"inner","code":0,"file":"/app/src/Baz.php:1"}}}`))
+	c.Assert(e, NotNil)
+	c.Assert(e.code, Equals, "12")
+	c.Assert(e.file, Equals, "/app/src/Foo.php")
+	c.Assert(e.line, Equals, 7)
+	c.Assert(e.trace, DeepEquals, []string{"/app/src/Bar.php:3"})
+	c.Assert(e.previous.class, Equals, "LogicException")
+
+	c.Assert(extractException([]byte(`[2017-10-04 00:27:08] request.INFO: Matched route "homepage". [] []`)), IsNil)
+}
+
+func (s *HumanlogSuite) TestFileLink(c *C) {
+	h := NewHandler(&Options{ProjectDir: "/app", FileLinkFormat: "vscode"})
+	c.Assert(h.fileLink("/app/src/Foo.php", 7), Equals, "<href=vscode://file//app/src/Foo.php:7>src/Foo.php:7</>")
+	c.Assert(h.frameLink("#0 /vendor/a.php(1): foo()"), Equals, "#0 <href=vscode://file//vendor/a.php:1>/vendor/a.php:1</> foo()")
+}
@@ -22,7 +22,6 @@ package humanlog
 import (
 	"regexp"
 	"strings"
-	"time"
 
 	"github.com/pkg/errors"
 )
@@ -62,7 +61,7 @@ func convertPHPFPMLog(in []byte) (*line, error) {
 	}
 	// convert date (Wed Aug 12 16:39:56 2020)
 	var err error
-	line.time, err = time.Parse(`2-Jan-2006 15:04:05`, allMatches[0][1])
+	line.time, err = parseTime(`2-Jan-2006 15:04:05`, allMatches[0][1])
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -37,49 +37,49 @@ func (s *HumanlogSuite) TestFPMLogConverter(c *C) {
 	}
 	expected := []*line{
 		{
-			time:    time.Date(2020, 9, 17, 12, 20, 03, 0, time.UTC),
+			time:    time.Date(2020, 9, 17, 12, 20, 03, 0, zoneless),
 			level:   "notice",
 			source:  "FPM",
 			message: "fpm is running, pid 83827",
 			fields:  map[string]string{},
 		},
 		{
-			time:    time.Date(2020, 9, 17, 12, 20, 03, 0, time.UTC),
+			time:    time.Date(2020, 9, 17, 12, 20, 03, 0, zoneless),
 			level:   "notice",
 			source:  "FPM",
 			message: "ready to handle connections",
 			fields:  map[string]string{},
 		},
 		{
-			time:    time.Date(2020, 9, 17, 12, 20, 26, 0, time.UTC),
+			time:    time.Date(2020, 9, 17, 12, 20, 26, 0, zoneless),
 			level:   "notice",
 			source:  "FPM",
 			message: "Terminating ...",
 			fields:  map[string]string{},
 		},
 		{
-			time:    time.Date(2020, 9, 17, 12, 20, 26, 0, time.UTC),
+			time:    time.Date(2020, 9, 17, 12, 20, 26, 0, zoneless),
 			level:   "notice",
 			source:  "FPM",
 			message: "exiting, bye-bye!",
 			fields:  map[string]string{},
 		},
 		{
-			time:    time.Date(2020, 9, 17, 12, 25, 28, 0, time.UTC),
+			time:    time.Date(2020, 9, 17, 12, 25, 28, 0, zoneless),
 			level:   "warning",
 			source:  "FPM",
 			message: `Unable to load dynamic library '/app/blackfire-20190902-zts.so' (tried: /app/blackfire-20190902-zts.so (dlopen(/app/blackfire-20190902-zts.so, 9): image not found), /usr/local/lib/php/pecl/20190902//app/blackfire-20190902-zts.so.so (dlopen(/usr/local/lib/php/pecl/20190902//app/blackfire-20190902-zts.so.so, 9): image not found)) in Unknown on line 0`,
 			fields:  map[string]string{},
 		},
 		{
-			time:    time.Date(2020, 9, 17, 12, 25, 48, 0, time.UTC),
+			time:    time.Date(2020, 9, 17, 12, 25, 48, 0, zoneless),
 			level:   "warning",
 			source:  "FPM",
 			message: `failed to open stream: No such file or directory in Unknown on line 0`,
 			fields:  map[string]string{},
 		},
 		{
-			time:    time.Date(2020, 9, 17, 12, 25, 48, 0, time.UTC),
+			time:    time.Date(2020, 9, 17, 12, 25, 48, 0, zoneless),
 			level:   "fatal",
 			source:  "FPM",
 			message: `Failed opening required 'foo.php' (include_path='.:/usr/local/Cellar/php/7.4.10/share/php/pear') in Unknown on line 0`,
@@ -36,10 +36,39 @@ import (
 // [12-Aug-2020 16:31:33] WARNING: [pool web] child 312 said into stdout: "[2020-08-12T18:31:33.470956+02:00] console.DEBUG: www {"xxx":"yyy","code":1} []"
 var PHPFPMLogLineRegexp = regexp.MustCompile(`^\[\d+\-[^\-]+\-\d+ \d+\:\d+\:[\d\.]+\] WARNING\: \[pool [^\]]+\] child \d+ said into std(?:err|out)\: "(.*)"\s*$`)
 
+// zoneless is the location of the timestamps logged without time zone
+// information until parse() moves them to the time zone of the logs
+var zoneless = time.FixedZone("", 0)
+
+// TimeFormat is how timestamps are displayed by Prettify
+type TimeFormat string
+
+const (
+	// TimeFormatStamp displays the date and time, with the year when it is
+	// not the current one
+	TimeFormatStamp TimeFormat = "stamp"
+	// TimeFormatISO displays ISO 8601 timestamps with milliseconds and zone
+	TimeFormatISO TimeFormat = "iso"
+	// TimeFormatRelative displays how long ago lines were logged
+	TimeFormatRelative TimeFormat = "relative"
+)
+
 type Options struct {
 	SkipUnchanged bool
 	LightBg       bool
 	WithSource    bool
+	// Location is the time zone used to display timestamps (local by default)
+	Location *time.Location
+	// ZonelessLocation is the time zone of the timestamps logged without one
+	// (local by default, like PHP and Symfony)
+	ZonelessLocation *time.Location
+	// TimeFormat is TimeFormatStamp by default
+	TimeFormat TimeFormat
+	// ProjectDir makes the exception file paths relative to the project
+	ProjectDir string
+	// FileLinkFormat is the link to files (like "phpstorm://open?file=%f&line=%l"
+	// or a known IDE name), SYMFONY_IDE or file:// links are used by default
+	FileLinkFormat string
 }
 
 type Handler struct {
@@ -50,11 +79,12 @@ type Handler struct {
 }
 
 type line struct {
-	level   string
-	time    time.Time
-	source  string
-	message string
-	fields  map[string]string
+	level     string
+	time      time.Time
+	source    string
+	message   string
+	fields    map[string]string
+	exception *exception
 }
 
 func NewHandler(opts *Options) *Handler {
@@ -65,38 +95,15 @@ func NewHandler(opts *Options) *Handler {
 
 func (h *Handler) Simplify(in []byte) []byte {
 	var line *line
-	var err error
 	h.mu.Lock()
 	defer func() {
 		h.lastLine = line
 		h.mu.Unlock()
 	}()
 
-	// remove the end newline
-	in = bytes.TrimRight(in, "\n")
-
-	// is it a PHP FPM line? (strip the first (irrelevant) part)
-	in = PHPFPMLogLineRegexp.ReplaceAll(in, []byte("$1"))
-
-	line, err = convertPHPLog(in)
-	if err != nil || line == nil {
-		line, err = convertPHPFPMLog(in)
-		if err != nil || line == nil {
-			// is it a Symfony log line?
-			line, err = convertSymfonyLog(in)
-			if err != nil {
-				return in
-			}
-			if line == nil {
-				if !bytes.Contains(in, []byte(`"time":`)) && !bytes.Contains(in, []byte(`"ts":`)) {
-					return in
-				}
-				line, err = unmarshal(in)
-				if err != nil {
-					return in
-				}
-			}
-		}
+	line, in = parse(in, h.opts.ZonelessLocation)
+	if line == nil {
+		return in
 	}
 
 	tweakHTTPLog(line)
@@ -112,46 +119,26 @@ func (h *Handler) Simplify(in []byte) []byte {
 
 func (h *Handler) Prettify(in []byte) []byte {
 	var line *line
-	var err error
 	h.mu.Lock()
 	defer func() {
 		h.lastLine = line
 		h.mu.Unlock()
 	}()
 
-	// remove the end newline
-	in = bytes.TrimRight(in, "\n")
-
-	// is it a PHP FPM line? (strip the first (irrelevant) part)
-	in = PHPFPMLogLineRegexp.ReplaceAll(in, []byte("$1"))
-
-	line, err = convertPHPLog(in)
-	if err != nil || line == nil {
-		line, err = convertPHPFPMLog(in)
-		if err != nil || line == nil {
-			// is it a Symfony log line?
-			line, err = convertSymfonyLog(in)
-			if err != nil {
-				return in
-			}
-			if line == nil {
-				if !bytes.Contains(in, []byte(`"time":`)) && !bytes.Contains(in, []byte(`"ts":`)) {
-					return in
-				}
-				line, err = unmarshal(in)
-				if err != nil {
-					return in
-				}
-			}
-		}
+	line, in = parse(in, h.opts.ZonelessLocation)
+	if line == nil {
+		return in
 	}
 
 	tweakHTTPLog(line)
 
 	var buf bytes.Buffer
-	buf.WriteString(line.time.Format(time.Stamp))
+	if t := h.formatTime(line.time); t != "" {
+		buf.WriteString(t)
+		buf.WriteString(" ")
+	}
 
-	buf.WriteString(" |")
+	buf.WriteString("|")
 	lvl := strings.ToUpper(line.level)
 	if len(lvl) > 7 {
 		lvl = lvl[:7]
@@ -189,10 +176,165 @@ func (h *Handler) Prettify(in []byte) []byte {
 	buf.WriteString(line.message)
 	buf.WriteString(" ")
 	buf.WriteString(strings.Join(h.joinKVs(line), " "))
+	if line.exception != nil {
+		h.renderException(&buf, line.exception)
+	}
 
 	return buf.Bytes()
 }
 
+// Entry holds the information extracted from a structured log line
+type Entry struct {
+	Level   string
+	Time    time.Time
+	Source  string
+	Message string
+	Fields  map[string]string
+	// Status is the HTTP status code of access log lines (0 otherwise)
+	Status int
+	// Exception is the exception found in the context, if any; it is not
+	// part of Fields
+	Exception *Exception
+}
+
+// Parse extracts the level, time, and HTTP status of a log line.
+// It returns nil when the line is not in a known format.
+func Parse(in []byte) *Entry {
+	return ParseInLocation(in, nil)
+}
+
+// ParseInLocation is like Parse but the timestamps logged without time zone
+// are in loc instead of the local time zone
+func ParseInLocation(in []byte, loc *time.Location) *Entry {
+	line, _ := parse(in, loc)
+	if line == nil {
+		return nil
+	}
+	entry := line.entry()
+	entry.Level = normalizeLevel(entry.Level)
+	return entry
+}
+
+// LevelSeverity returns the severity of a log level (higher is more severe)
+// or -1 if the level is unknown.
+func LevelSeverity(level string) int {
+	switch normalizeLevel(level) {
+	case "debug":
+		return 0
+	case "info":
+		return 1
+	case "notice":
+		return 2
+	case "warning":
+		return 3
+	case "error":
+		return 4
+	case "critical":
+		return 5
+	case "alert":
+		return 6
+	case "emergency":
+		return 7
+	}
+	return -1
+}
+
+func normalizeLevel(level string) string {
+	level = strings.ToLower(level)
+	switch level {
+	case "warn":
+		return "warning"
+	case "err":
+		return "error"
+	case "fatal", "panic", "crit":
+		return "critical"
+	case "emerg":
+		return "emergency"
+	case "trace":
+		return "debug"
+	}
+	return level
+}
+
+// parse converts a raw log line; the returned line is nil when the format is
+// not recognized, in which case the cleaned-up input should be used as is.
+// Timestamps without time zone are in loc (local when nil).
+func parse(in []byte, loc *time.Location) (*line, []byte) {
+	// remove the end newline
+	in = bytes.TrimRight(in, "\n")
+
+	// is it a PHP FPM line? (strip the first (irrelevant) part)
+	in = PHPFPMLogLineRegexp.ReplaceAll(in, []byte("$1"))
+
+	for _, p := range Parsers() {
+		var line *line
+		if lp, ok := p.(*lineParser); ok {
+			if l, err := lp.convert(in); err == nil {
+				line = l
+			}
+		} else if entry, err := p.Parse(in); err == nil && entry != nil {
+			line = newLineFromEntry(entry)
+		}
+		if line == nil {
+			continue
+		}
+		line.time = inLocation(line.time, loc)
+		if line.exception = extractException(in); line.exception != nil {
+			// rendered separately
+			delete(line.fields, "exception")
+		}
+		return line, in
+	}
+	return nil, in
+}
+
+// formatTime returns an empty string for lines without timestamp
+func (h *Handler) formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	loc := h.opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	t = t.In(loc)
+	switch h.opts.TimeFormat {
+	case TimeFormatISO:
+		return t.Format("2006-01-02T15:04:05.000Z07:00")
+	case TimeFormatRelative:
+		return fmt.Sprintf("%10s", formatAgo(time.Since(t)))
+	}
+	if t.Year() != time.Now().In(loc).Year() {
+		return t.Format("Jan _2 2006 15:04:05")
+	}
+	return t.Format(time.Stamp)
+}
+
+func formatAgo(d time.Duration) string {
+	switch {
+	case d < 0:
+		return "now"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
+
+// ParseTimeFormat validates a display time format
+func ParseTimeFormat(format string) (TimeFormat, error) {
+	switch f := TimeFormat(format); f {
+	case "", TimeFormatStamp:
+		return TimeFormatStamp, nil
+	case TimeFormatISO, TimeFormatRelative:
+		return f, nil
+	}
+	return "", errors.Errorf("unknown time format \"%s\" (stamp, iso, or relative)", format)
+}
+
 func (h *Handler) joinKVs(line *line) []string {
 	kv := make([]string, 0, len(line.fields))
 	for k, v := range line.fields {
@@ -211,6 +353,13 @@ func (h *Handler) joinKVs(line *line) []string {
 	return kv
 }
 
+func convertJSONLog(in []byte) (*line, error) {
+	if !bytes.Contains(in, []byte(`"time":`)) && !bytes.Contains(in, []byte(`"ts":`)) {
+		return nil, nil
+	}
+	return unmarshal(in)
+}
+
 func unmarshal(data []byte) (*line, error) {
 	raw := make(map[string]interface{})
 	err := errors.WithStack(json.Unmarshal(data, &raw))
@@ -349,11 +498,45 @@ func tryParseTime(value string) (time.Time, bool) {
 	var t time.Time
 	var err error
 	for _, layout := range formats {
-		t, err = time.Parse(layout, value)
+		t, err = parseTime(layout, value)
 		if err == nil {
-			return t, true
+			return withYear(t), true
 		}
 	}
 
 	return t, false
 }
+
+// parseTime parses a timestamp; it is in the zoneless location when the
+// layout has no time zone information
+func parseTime(layout, value string) (time.Time, error) {
+	if strings.Contains(layout, "MST") || strings.Contains(layout, "-07") || strings.Contains(layout, "Z07") {
+		return time.Parse(layout, value)
+	}
+	return time.ParseInLocation(layout, value, zoneless)
+}
+
+// inLocation moves a timestamp logged without time zone to loc (local when nil)
+func inLocation(t time.Time, loc *time.Location) time.Time {
+	if t.Location() != zoneless {
+		return t
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// withYear sets the year of timestamps logged without one (like time.Stamp)
+// to the most recent year that does not put them in the future
+func withYear(t time.Time) time.Time {
+	if t.Year() != 0 {
+		return t
+	}
+	now := time.Now().In(t.Location())
+	t = t.AddDate(now.Year(), 0, 0)
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t
+}
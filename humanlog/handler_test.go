@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package humanlog
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *HumanlogSuite) TestParse(c *C) {
+	entry := Parse([]byte(`[2018-01-29 07:08:59] request.WARNING: Something happened. [] []`))
+	c.Assert(entry, NotNil)
+	c.Assert(entry.Level, Equals, "warning")
+	c.Assert(entry.Source, Equals, "request")
+	c.Assert(entry.Time.Format("2006-01-02 15:04:05"), Equals, "2018-01-29 07:08:59")
+	c.Assert(entry.Status, Equals, 0)
+
+	entry = Parse([]byte(`{"level":"info","ip":"127.0.0.1","status":404,"method":"GET","time":"2021-01-05T10:00:00+01:00","message":"/missing"}`))
+	c.Assert(entry, NotNil)
+	c.Assert(entry.Status, Equals, 404)
+
+	c.Assert(Parse([]byte("not a log line")), IsNil)
+}
+
+func (s *HumanlogSuite) TestLevelSeverity(c *C) {
+	c.Assert(LevelSeverity("WARN") > LevelSeverity("notice"), Equals, true)
+	c.Assert(LevelSeverity("fatal"), Equals, LevelSeverity("critical"))
+	c.Assert(LevelSeverity("unknown"), Equals, -1)
+}
+
+func (s *HumanlogSuite) TestFormatTime(c *C) {
+	t := time.Date(2020, 9, 17, 12, 20, 3, 0, time.UTC)
+	h := NewHandler(&Options{Location: time.UTC})
+	c.Assert(h.formatTime(t), Equals, "Sep 17 2020 12:20:03")
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	c.Assert(err, IsNil)
+	h = NewHandler(&Options{Location: paris, TimeFormat: TimeFormatISO})
+	c.Assert(h.formatTime(t), Equals, "2020-09-17T14:20:03.000+02:00")
+
+	h = NewHandler(&Options{TimeFormat: TimeFormatRelative})
+	c.Assert(h.formatTime(time.Now().Add(-90*time.Second)), Equals, "    1m ago")
+	c.Assert(h.formatTime(time.Time{}), Equals, "")
+}
+
+func (s *HumanlogSuite) TestZonelessLocation(c *C) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	c.Assert(err, IsNil)
+
+	entry := ParseInLocation([]byte(`[2018-01-29 07:08:59] app.INFO: hello [] []`), paris)
+	c.Assert(entry, NotNil)
+	c.Assert(entry.Time.Equal(time.Date(2018, 1, 29, 7, 8, 59, 0, paris)), Equals, true)
+	// timestamps with a time zone are not affected
+	entry = ParseInLocation([]byte(`[2019-11-13T07:22:27.000000+01:00] app.INFO: hello [] []`), time.UTC)
+	c.Assert(entry, NotNil)
+	c.Assert(entry.Time.Equal(time.Date(2019, 11, 13, 6, 22, 27, 0, time.UTC)), Equals, true)
+
+	h := NewHandler(&Options{Location: time.UTC, ZonelessLocation: paris})
+	c.Assert(string(h.Prettify([]byte(`[2018-01-29 07:08:59] app.INFO: hello [] []`))), Matches, `Jan 29 2018 06:08:59 \|INFO   \| hello.*`)
+	// lines without timestamp are displayed without one
+	c.Assert(string(h.Prettify([]byte(`level=info msg=hello`))), Matches, `\|INFO   \| hello.*`)
+}
+
+func (s *HumanlogSuite) TestTimestampWithoutYear(c *C) {
+	t, ok := tryParseTime(time.Now().Add(-time.Hour).Format(time.Stamp))
+	c.Assert(ok, Equals, true)
+	c.Assert(t.Year(), Equals, time.Now().Add(-time.Hour).Year())
+}
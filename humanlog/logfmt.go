@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package humanlog
+
+import (
+	"strconv"
+	"strings"
+)
+
+// logfmt, used by logrus (text formatter) and many Go libraries
+// time="2021-04-07T14:52:43+02:00" level=info msg="hello world" port=8000
+func convertLogfmtLog(in []byte) (*line, error) {
+	pairs, ok := parseLogfmt(string(in))
+	if !ok {
+		return nil, nil
+	}
+	line := &line{
+		fields: make(map[string]string),
+	}
+	var hasLevel, hasMessage bool
+	for _, pair := range pairs {
+		switch pair[0] {
+		case "level", "lvl":
+			line.level = strings.ToLower(pair[1])
+			hasLevel = true
+		case "msg", "message":
+			line.message = pair[1]
+			hasMessage = true
+		case "time", "ts", "t":
+			if t, ok := tryParseTime(pair[1]); ok {
+				line.time = t
+			} else {
+				line.fields[pair[0]] = convertAnyVal(pair[1])
+			}
+		case "source", "logger", "component":
+			line.source = pair[1]
+		default:
+			if _, err := strconv.ParseFloat(pair[1], 64); err == nil {
+				line.fields[pair[0]] = pair[1]
+			} else {
+				line.fields[pair[0]] = convertAnyVal(pair[1])
+			}
+		}
+	}
+	if !hasLevel || !hasMessage {
+		return nil, nil
+	}
+	return line, nil
+}
+
+// parseLogfmt returns the key/value pairs of a logfmt line, in order
+func parseLogfmt(in string) ([][2]string, bool) {
+	pairs := [][2]string{}
+	for {
+		in = strings.TrimLeft(in, " ")
+		if in == "" {
+			return pairs, len(pairs) > 0
+		}
+		eq := strings.IndexAny(in, "= ")
+		if eq <= 0 || in[eq] != '=' {
+			return nil, false
+		}
+		key := in[:eq]
+		in = in[eq+1:]
+		var value string
+		if strings.HasPrefix(in, `"`) {
+			end := 1
+			for ; end < len(in); end++ {
+				if in[end] == '\\' {
+					end++
+				} else if in[end] == '"' {
+					break
+				}
+			}
+			if end >= len(in) {
+				return nil, false
+			}
+			unquoted, err := strconv.Unquote(in[:end+1])
+			if err != nil {
+				return nil, false
+			}
+			value = unquoted
+			in = in[end+1:]
+		} else {
+			end := strings.IndexByte(in, ' ')
+			if end == -1 {
+				end = len(in)
+			}
+			value = in[:end]
+			in = in[end:]
+		}
+		pairs = append(pairs, [2]string{key, value})
+	}
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package humanlog
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Parser converts the log lines of a given format
+type Parser interface {
+	// Name identifies the format
+	Name() string
+	// Parse returns nil when the line is not in the format of the parser
+	Parse(in []byte) (*Entry, error)
+}
+
+var (
+	parsersMu     sync.RWMutex
+	customParsers []Parser
+	// built-in parsers, from the most specific format to the most generic one
+	builtinParsers = []Parser{
+		&lineParser{name: "php", convert: convertPHPLog},
+		&lineParser{name: "php-fpm", convert: convertPHPFPMLog},
+		&lineParser{name: "symfony", convert: convertSymfonyLog},
+		&lineParser{name: "combined", convert: convertCombinedLog},
+		&lineParser{name: "zap", convert: convertZapLog},
+		&lineParser{name: "pino", convert: convertPinoLog},
+		&lineParser{name: "json", convert: convertJSONLog},
+		&lineParser{name: "logfmt", convert: convertLogfmtLog},
+	}
+)
+
+// RegisterParser registers a parser, tried before the built-in ones; it
+// replaces any registered parser with the same name
+func RegisterParser(p Parser) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+
+	for i, registered := range customParsers {
+		if registered.Name() == p.Name() {
+			customParsers[i] = p
+			return
+		}
+	}
+	customParsers = append(customParsers, p)
+}
+
+// Parsers returns the parsers in the order they are tried
+func Parsers() []Parser {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+
+	parsers := make([]Parser, 0, len(customParsers)+len(builtinParsers))
+	parsers = append(parsers, customParsers...)
+	return append(parsers, builtinParsers...)
+}
+
+// lineParser adapts the built-in converters to the Parser interface
+type lineParser struct {
+	name    string
+	convert func(in []byte) (*line, error)
+}
+
+func (p *lineParser) Name() string {
+	return p.name
+}
+
+func (p *lineParser) Parse(in []byte) (*Entry, error) {
+	line, err := p.convert(in)
+	if err != nil || line == nil {
+		return nil, err
+	}
+	line.time = inLocation(line.time, nil)
+	return line.entry(), nil
+}
+
+func (l *line) entry() *Entry {
+	entry := &Entry{
+		Level:     l.level,
+		Time:      l.time,
+		Source:    l.source,
+		Message:   l.message,
+		Fields:    l.fields,
+		Exception: l.exception.export(),
+	}
+	if status, ok := l.fields["status"]; ok {
+		entry.Status, _ = strconv.Atoi(strings.Trim(status, `"`))
+	}
+	return entry
+}
+
+func newLineFromEntry(entry *Entry) *line {
+	fields := entry.Fields
+	if fields == nil {
+		fields = make(map[string]string)
+	}
+	level := strings.ToLower(entry.Level)
+	if level == "" {
+		level = "????"
+	}
+	return &line{
+		level:   level,
+		time:    entry.Time,
+		source:  entry.Source,
+		message: entry.Message,
+		fields:  fields,
+	}
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package humanlog
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *HumanlogSuite) TestCombinedLogConverter(c *C) {
+	line, err := convertCombinedLog([]byte(`127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 404 2326 "http://www.example.com/start.html" "Mozilla/4.08"`))
+	c.Assert(err, IsNil)
+	c.Assert(line, NotNil)
+	c.Assert(line.level, Equals, "warning")
+	c.Assert(line.message, Equals, "/apache_pb.gif")
+	c.Assert(line.time.Equal(time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC)), Equals, true)
+	c.Assert(line.fields["status"], Equals, "404")
+	c.Assert(line.fields["method"], Equals, `"GET"`)
+	c.Assert(line.fields["user"], Equals, `"frank"`)
+}
+
+func (s *HumanlogSuite) TestPinoLogConverter(c *C) {
+	line, err := convertPinoLog([]byte(`{"level":50,"time":1531171074631,"pid":657,"hostname":"box","msg":"failed","v":1}`))
+	c.Assert(err, IsNil)
+	c.Assert(line, NotNil)
+	c.Assert(line.level, Equals, "error")
+	c.Assert(line.message, Equals, "failed")
+	c.Assert(line.time.UnixNano(), Equals, int64(1531171074631)*int64(time.Millisecond))
+	c.Assert(line.fields, DeepEquals, map[string]string{"pid": "657", "hostname": `"box"`})
+}
+
+func (s *HumanlogSuite) TestZapLogConverter(c *C) {
+	line, err := convertZapLog([]byte("2021-04-07T14:52:43.123+0200\tINFO\thttp\tapp/main.go:20\tlistening\t{\"port\": 8000}"))
+	c.Assert(err, IsNil)
+	c.Assert(line, NotNil)
+	c.Assert(line.level, Equals, "info")
+	c.Assert(line.source, Equals, "http")
+	c.Assert(line.message, Equals, "listening")
+	c.Assert(line.fields, DeepEquals, map[string]string{"port": "8000", "caller": `"app/main.go:20"`})
+}
+
+func (s *HumanlogSuite) TestLogfmtLogConverter(c *C) {
+	line, err := convertLogfmtLog([]byte(`time="2021-04-07T14:52:43+02:00" level=warning msg="disk \"data\" is full" usage=98.5 disk=data`))
+	c.Assert(err, IsNil)
+	c.Assert(line, NotNil)
+	c.Assert(line.level, Equals, "warning")
+	c.Assert(line.message, Equals, `disk "data" is full`)
+	c.Assert(line.fields, DeepEquals, map[string]string{"usage": "98.5", "disk": `"data"`})
+
+	line, err = convertLogfmtLog([]byte(`just a sentence with key=value`))
+	c.Assert(err, IsNil)
+	c.Assert(line, IsNil)
+}
+
+func (s *HumanlogSuite) TestRegexpParser(c *C) {
+	_, err := NewRegexpParser("broken", `^(?P<level>\w+)`, "")
+	c.Assert(err, NotNil)
+
+	p, err := NewRegexpParser("custom", `^(?P<time>\S+) <(?P<level>\w+)> (?P<request_id>\w+) (?P<message>.*)$`, "2006-01-02T15:04:05")
+	c.Assert(err, IsNil)
+	RegisterParser(p)
+	entry := Parse([]byte(`2021-04-07T14:52:43 <WARN> abc123 something happened`))
+	c.Assert(entry, NotNil)
+	c.Assert(entry.Level, Equals, "warning")
+	c.Assert(entry.Message, Equals, "something happened")
+	c.Assert(entry.Fields["request_id"], Equals, `"abc123"`)
+}
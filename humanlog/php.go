@@ -22,7 +22,6 @@ package humanlog
 import (
 	"regexp"
 	"strings"
-	"time"
 
 	"github.com/pkg/errors"
 )
@@ -44,7 +43,7 @@ func convertPHPLog(in []byte) (*line, error) {
 	// convert date (Wed Aug 12 16:39:56 2020)
 	var err error
 	m := string(allMatches[0][1])
-	line.time, err = time.Parse(`Mon Jan 2 15:04:05 2006`, m)
+	line.time, err = parseTime(`Mon Jan 2 15:04:05 2006`, m)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
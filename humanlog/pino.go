@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package humanlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// pino (Node.js) JSON logs have numeric levels and millisecond timestamps
+// {"level":30,"time":1531171074631,"pid":657,"hostname":"box","msg":"hello"}
+var pinoLevels = map[float64]string{
+	10: "trace",
+	20: "debug",
+	30: "info",
+	40: "warning",
+	50: "error",
+	60: "fatal",
+}
+
+func convertPinoLog(in []byte) (*line, error) {
+	if !bytes.HasPrefix(in, []byte("{")) || !bytes.Contains(in, []byte(`"level":`)) {
+		return nil, nil
+	}
+	raw := make(map[string]interface{})
+	if err := json.Unmarshal(in, &raw); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	lvl, ok := raw["level"].(float64)
+	if !ok {
+		return nil, nil
+	}
+	level, ok := pinoLevels[lvl]
+	if !ok {
+		return nil, nil
+	}
+	line := &line{
+		level:  level,
+		fields: make(map[string]string),
+	}
+	if ms, ok := raw["time"].(float64); ok {
+		line.time = time.Unix(0, int64(ms)*int64(time.Millisecond))
+	}
+	line.message, _ = raw["msg"].(string)
+	line.source, _ = raw["name"].(string)
+	for _, key := range []string{"level", "time", "msg", "name", "v"} {
+		delete(raw, key)
+	}
+	for key, val := range raw {
+		line.fields[key] = convertAnyVal(val)
+	}
+	return line, nil
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package humanlog
+
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// RegexpParser parses lines with a regular expression: the "time", "level",
+// "source", and "message" named groups are mapped to the entry and the other
+// named groups are added as fields
+type RegexpParser struct {
+	name       string
+	re         *regexp.Regexp
+	timeFormat string
+}
+
+// NewRegexpParser creates a parser for a custom format; timeFormat is a Go
+// time layout (common layouts are tried when empty)
+func NewRegexpParser(name, pattern, timeFormat string) (*RegexpParser, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid regular expression for the \"%s\" log format", name)
+	}
+	if re.SubexpIndex("message") == -1 {
+		return nil, errors.Errorf("the regular expression for the \"%s\" log format must have a \"message\" named group", name)
+	}
+	return &RegexpParser{
+		name:       name,
+		re:         re,
+		timeFormat: timeFormat,
+	}, nil
+}
+
+func (p *RegexpParser) Name() string {
+	return p.name
+}
+
+func (p *RegexpParser) Parse(in []byte) (*Entry, error) {
+	matches := p.re.FindSubmatch(in)
+	if matches == nil {
+		return nil, nil
+	}
+	entry := &Entry{
+		Fields: make(map[string]string),
+	}
+	for i, group := range p.re.SubexpNames() {
+		if group == "" || i >= len(matches) {
+			continue
+		}
+		value := string(matches[i])
+		switch group {
+		case "time":
+			var ok bool
+			if p.timeFormat == "" {
+				entry.Time, ok = tryParseTime(value)
+			} else {
+				t, err := parseTime(p.timeFormat, value)
+				entry.Time, ok = t, err == nil
+			}
+			if !ok {
+				return nil, errors.Errorf("unable to parse time \"%s\" for the \"%s\" log format", value, p.name)
+			}
+		case "level":
+			entry.Level = value
+		case "source":
+			entry.Source = value
+		case "message":
+			entry.Message = value
+		default:
+			entry.Fields[group] = convertAnyVal(value)
+		}
+	}
+	return entry, nil
+}
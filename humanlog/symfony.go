@@ -46,7 +46,7 @@ func convertSymfonyLog(in []byte) (*line, error) {
 	for i, m := range matches {
 		if i == 1 {
 			// convert date (2018-11-19 13:32:00)
-			line.time, err = time.Parse(`2006-01-02 15:04:05`, string(m))
+			line.time, err = parseTime(`2006-01-02 15:04:05`, string(m))
 			if err != nil {
 				// convert date (2019-11-13T07:16:50.260544+01:00)
 				line.time, err = time.Parse(time.RFC3339Nano, string(m))
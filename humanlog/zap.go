@@ -0,0 +1,79 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package humanlog
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// zap (Go) console encoder, tab separated
+// 2021-04-07T14:52:43.123+0200	INFO	app/main.go:20	hello	{"port": 8000}
+var zapLevels = map[string]string{
+	"DEBUG":  "debug",
+	"INFO":   "info",
+	"WARN":   "warning",
+	"ERROR":  "error",
+	"DPANIC": "critical",
+	"PANIC":  "critical",
+	"FATAL":  "critical",
+}
+
+var zapCallerRegexp = regexp.MustCompile(`^[\w./-]+\.go:\d+$`)
+
+func convertZapLog(in []byte) (*line, error) {
+	parts := strings.Split(string(in), "\t")
+	if len(parts) < 3 {
+		return nil, nil
+	}
+	level, ok := zapLevels[strings.ToUpper(parts[1])]
+	if !ok {
+		return nil, nil
+	}
+	t, ok := tryParseTime(parts[0])
+	if !ok {
+		return nil, nil
+	}
+	line := &line{
+		time:   t,
+		level:  level,
+		fields: make(map[string]string),
+	}
+	rest := parts[2:]
+	if last := rest[len(rest)-1]; len(rest) > 1 && strings.HasPrefix(last, "{") {
+		raw := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(last), &raw); err == nil {
+			for key, val := range raw {
+				line.fields[key] = convertAnyVal(val)
+			}
+			rest = rest[:len(rest)-1]
+		}
+	}
+	line.message = rest[len(rest)-1]
+	for _, part := range rest[:len(rest)-1] {
+		if zapCallerRegexp.MatchString(part) {
+			line.fields["caller"] = convertAnyVal(part)
+		} else {
+			line.source = part
+		}
+	}
+	return line, nil
+}
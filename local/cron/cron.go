@@ -0,0 +1,173 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package cron parses cron specs as used by Platform.sh crons
+package cron
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// Schedule is a parsed cron spec
+type Schedule struct {
+	minutes, hours, days, months, weekdays map[int]bool
+	// when both day fields are restricted, a day matches if any of them matches
+	daysRestricted, weekdaysRestricted bool
+}
+
+// Parse parses a standard 5 fields cron spec ("*/5 * * * *") or a macro ("@daily")
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if macro, ok := macros[spec]; ok {
+		spec = macro
+	}
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, errors.Errorf("invalid cron spec \"%s\": expected %d fields, got %d", spec, len(fields), len(parts))
+	}
+
+	values := make([]map[int]bool, len(fields))
+	for i, part := range parts {
+		v, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cron spec \"%s\"", spec)
+		}
+		values[i] = v
+	}
+	// Sunday can be written 0 or 7
+	if values[4][7] {
+		values[4][0] = true
+	}
+
+	return &Schedule{
+		minutes:            values[0],
+		hours:              values[1],
+		days:               values[2],
+		months:             values[3],
+		weekdays:           values[4],
+		daysRestricted:     parts[2] != "*",
+		weekdaysRestricted: parts[4] != "*",
+	}, nil
+}
+
+func parseField(part string, f field) (map[int]bool, error) {
+	max := f.max
+	if f.name == "day of week" {
+		max = 7
+	}
+	values := make(map[int]bool)
+	for _, item := range strings.Split(part, ",") {
+		step := 1
+		if i := strings.Index(item, "/"); i != -1 {
+			s, err := strconv.Atoi(item[i+1:])
+			if err != nil || s <= 0 {
+				return nil, errors.Errorf("invalid step in %s field \"%s\"", f.name, part)
+			}
+			step = s
+			item = item[:i]
+		}
+		from, to := f.min, max
+		if item != "*" {
+			bounds := strings.SplitN(item, "-", 2)
+			var err error
+			if from, err = strconv.Atoi(bounds[0]); err != nil {
+				return nil, errors.Errorf("invalid %s field \"%s\"", f.name, part)
+			}
+			to = from
+			if len(bounds) == 2 {
+				if to, err = strconv.Atoi(bounds[1]); err != nil {
+					return nil, errors.Errorf("invalid %s field \"%s\"", f.name, part)
+				}
+			} else if step > 1 {
+				// "5/10" means from 5 to the max every 10
+				to = max
+			}
+		}
+		if from < f.min || to > max || from > to {
+			return nil, errors.Errorf("out of range %s field \"%s\"", f.name, part)
+		}
+		for v := from; v <= to; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+// Next returns the first time matching the schedule strictly after t
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// a matching time always exists in the next 5 years (for February 29th)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !s.months[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.hours[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.minutes[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	day := s.days[t.Day()]
+	weekday := s.weekdays[int(t.Weekday())]
+	if s.daysRestricted && s.weekdaysRestricted {
+		return day || weekday
+	}
+	return day && weekday
+}
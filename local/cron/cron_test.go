@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cron
+
+import (
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type CronSuite struct{}
+
+var _ = Suite(&CronSuite{})
+
+func (s *CronSuite) TestNext(c *C) {
+	from := time.Date(2022, 11, 15, 10, 42, 30, 0, time.UTC)
+	for spec, expected := range map[string]time.Time{
+		"* * * * *":        time.Date(2022, 11, 15, 10, 43, 0, 0, time.UTC),
+		"*/5 * * * *":      time.Date(2022, 11, 15, 10, 45, 0, 0, time.UTC),
+		"0 * * * *":        time.Date(2022, 11, 15, 11, 0, 0, 0, time.UTC),
+		"@daily":           time.Date(2022, 11, 16, 0, 0, 0, 0, time.UTC),
+		"30 2 * * 1-5":     time.Date(2022, 11, 16, 2, 30, 0, 0, time.UTC),
+		"0 0 * * 7":        time.Date(2022, 11, 20, 0, 0, 0, 0, time.UTC),
+		"0 0 1 1 *":        time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		"15,45 9-17 * * *": time.Date(2022, 11, 15, 10, 45, 0, 0, time.UTC),
+		"0 0 29 2 *":       time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		// day of month OR day of week when both are restricted
+		"0 0 1 * 5": time.Date(2022, 11, 18, 0, 0, 0, 0, time.UTC),
+	} {
+		schedule, err := Parse(spec)
+		c.Assert(err, IsNil, Commentf(spec))
+		c.Check(schedule.Next(from), Equals, expected, Commentf(spec))
+	}
+}
+
+func (s *CronSuite) TestParseErrors(c *C) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "a * * * *", "5-1 * * * *", "* * 0 * *"} {
+		_, err := Parse(spec)
+		c.Check(err, NotNil, Commentf(spec))
+	}
+}
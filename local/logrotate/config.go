@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package logrotate
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Config configures the rotation of the logs written by the CLI
+// (web server, workers, proxy)
+type Config struct {
+	// MaxSize is the size in bytes after which a log file is rotated (0 disables)
+	MaxSize int64
+	// MaxAge is the duration after which a log file is rotated (0 disables)
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated files to keep
+	MaxBackups int
+	// Compress gzips rotated files
+	Compress bool
+}
+
+type fileConfig struct {
+	MaxSize    int64  `json:"max_size"`
+	MaxAge     string `json:"max_age"`
+	MaxBackups int    `json:"max_backups"`
+	Compress   bool   `json:"compress"`
+}
+
+var DefaultConfig = []byte(`{
+	"max_size": 10485760,
+	"max_age": "168h",
+	"max_backups": 5,
+	"compress": true
+}
+`)
+
+// LoadConfig loads the global log rotation configuration from
+// log_rotation.json in the CLI home directory
+func LoadConfig(homeDir string) (*Config, error) {
+	configFile := filepath.Join(homeDir, "log_rotation.json")
+	if _, err := os.Stat(configFile); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+			return nil, errors.Wrapf(err, "unable to create directory for %s", configFile)
+		}
+		if err := ioutil.WriteFile(configFile, DefaultConfig, 0644); err != nil {
+			return nil, errors.Wrapf(err, "unable to write %s", configFile)
+		}
+	}
+	data, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read the log rotation configuration file, %s", configFile)
+	}
+	var fc fileConfig
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse the JSON log rotation configuration file, %s", configFile)
+	}
+	config := &Config{
+		MaxSize:    fc.MaxSize,
+		MaxBackups: fc.MaxBackups,
+		Compress:   fc.Compress,
+	}
+	if fc.MaxAge != "" {
+		if config.MaxAge, err = time.ParseDuration(fc.MaxAge); err != nil {
+			return nil, errors.Wrapf(err, "invalid max_age in %s", configFile)
+		}
+	}
+	if config.MaxSize < 0 || config.MaxAge < 0 || config.MaxBackups < 0 {
+		return nil, errors.Errorf("log rotation settings must not be negative in %s", configFile)
+	}
+	return config, nil
+}
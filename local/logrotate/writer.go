@@ -0,0 +1,265 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package logrotate
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const backupTimeFormat = "20060102-150405.000000000"
+
+// Writer is a log file writer rotating the file when it becomes too large or
+// too old; rotated files are named after the log file with a timestamp suffix.
+type Writer struct {
+	filename string
+	config   *Config
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// Open opens a log file for writing. When rotate is true, the current content
+// of the file is archived first so that the file starts empty.
+func Open(filename string, config *Config, rotate bool) (*Writer, error) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	w := &Writer{
+		filename: filename,
+		config:   config,
+	}
+	if rotate {
+		if err := Archive(filename, config); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.file.Close()
+}
+
+func (w *Writer) shouldRotate(n int64) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.config.MaxSize > 0 && w.size+n > w.config.MaxSize {
+		return true
+	}
+	return w.config.MaxAge > 0 && time.Since(w.openedAt) > w.config.MaxAge
+}
+
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.WithStack(err)
+	}
+	w.file = f
+	w.size = info.Size()
+	w.openedAt = time.Now()
+	return nil
+}
+
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := Archive(w.filename, w.config); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+// Archive moves a log file aside and removes old backups
+func Archive(filename string, config *Config) error {
+	info, err := os.Stat(filename)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if info.Size() == 0 || config.MaxBackups == 0 {
+		return errors.WithStack(os.Remove(filename))
+	}
+
+	backup := backupName(filename, time.Now())
+	if err := os.Rename(filename, backup); err != nil {
+		return errors.WithStack(err)
+	}
+	if config.Compress {
+		if err := compress(backup); err != nil {
+			return err
+		}
+	}
+	return prune(filename, config.MaxBackups)
+}
+
+func backupName(filename string, t time.Time) string {
+	for {
+		backup := filename + "-" + t.Format(backupTimeFormat)
+		if _, err := os.Stat(backup); os.IsNotExist(err) {
+			if _, err := os.Stat(backup + ".gz"); os.IsNotExist(err) {
+				return backup
+			}
+		}
+		t = t.Add(time.Nanosecond)
+	}
+}
+
+func prune(filename string, maxBackups int) error {
+	backups := Backups(filename)
+	if len(backups) <= maxBackups {
+		return nil
+	}
+	for _, backup := range backups[maxBackups:] {
+		if err := os.Remove(backup); err != nil && !os.IsNotExist(err) {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+func compress(filename string) error {
+	src, err := os.Open(filename)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(filename+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		return errors.WithStack(err)
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		return errors.WithStack(err)
+	}
+	if err := dst.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Remove(filename))
+}
+
+// Backups returns the rotated files of a log file, most recent first
+func Backups(filename string) []string {
+	matches, err := filepath.Glob(filename + "-*")
+	if err != nil {
+		return nil
+	}
+	backups := []string{}
+	for _, match := range matches {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(match, filename+"-"), ".gz")
+		if _, err := time.Parse(backupTimeFormat, suffix); err != nil {
+			continue
+		}
+		backups = append(backups, match)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	return backups
+}
+
+// ReadBackupLines returns the last n lines stored in the rotated files of a
+// log file, oldest first
+func ReadBackupLines(filename string, n int64) ([]string, error) {
+	var lines []string
+	for _, backup := range Backups(filename) {
+		if int64(len(lines)) >= n {
+			break
+		}
+		backupLines, err := readLines(backup)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(backupLines, lines...)
+	}
+	if int64(len(lines)) > n {
+		lines = lines[int64(len(lines))-n:]
+	}
+	return lines, nil
+}
+
+func readLines(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(filename, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read %s", filename)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, errors.WithStack(scanner.Err())
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package logrotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type LogrotateSuite struct{}
+
+var _ = Suite(&LogrotateSuite{})
+
+func (s *LogrotateSuite) TestRotation(c *C) {
+	dir := c.MkDir()
+	filename := filepath.Join(dir, "app.log")
+	config := &Config{MaxSize: 10, MaxBackups: 2, Compress: true}
+	c.Assert(ioutil.WriteFile(filename, []byte("previous\n"), 0644), IsNil)
+
+	w, err := Open(filename, config, true)
+	c.Assert(err, IsNil)
+	c.Assert(Backups(filename), HasLen, 1)
+
+	for _, line := range []string{"one 1234\n", "two 1234\n", "three 12\n"} {
+		_, err := w.Write([]byte(line))
+		c.Assert(err, IsNil)
+	}
+	c.Assert(w.Close(), IsNil)
+
+	content, err := ioutil.ReadFile(filename)
+	c.Assert(err, IsNil)
+	c.Assert(string(content), Equals, "three 12\n")
+	c.Assert(Backups(filename), HasLen, 2)
+
+	lines, err := ReadBackupLines(filename, 5)
+	c.Assert(err, IsNil)
+	c.Assert(lines, DeepEquals, []string{"one 1234", "two 1234"})
+}
+
+func (s *LogrotateSuite) TestNoBackups(c *C) {
+	dir := c.MkDir()
+	filename := filepath.Join(dir, "app.log")
+	c.Assert(ioutil.WriteFile(filename, []byte("previous\n"), 0644), IsNil)
+
+	w, err := Open(filename, &Config{}, true)
+	c.Assert(err, IsNil)
+	c.Assert(w.Close(), IsNil)
+	c.Assert(Backups(filename), HasLen, 0)
+	info, err := os.Stat(filename)
+	c.Assert(err, IsNil)
+	c.Assert(info.Size(), Equals, int64(0))
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package logs
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/symfony-cli/symfony-cli/humanlog"
+)
+
+// Filter selects the log lines displayed by the tailer.
+// Filters on level, time, and status only keep lines that can be parsed and
+// carry the relevant information.
+type Filter struct {
+	// Sources restricts lines to the given sources (case insensitive)
+	Sources []string
+	// MinLevel is the minimum level of the lines to display
+	MinLevel string
+	// Pattern matches the raw content of the lines
+	Pattern *regexp.Regexp
+	// Since drops lines logged before that time
+	Since time.Time
+	// StatusMin and StatusMax filter access log lines by HTTP status
+	StatusMin int
+	StatusMax int
+}
+
+// ParseSince parses a relative duration (10m) or an absolute time.
+func ParseSince(value string) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("invalid time \"%s\", use a duration (10m) or a date (2006-01-02 15:04:05)", value)
+}
+
+// ParseStatusRange parses an HTTP status range like 404, 4xx, or 400-499.
+func ParseStatusRange(value string) (int, int, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if len(value) == 3 && strings.HasSuffix(value, "xx") {
+		class, err := strconv.Atoi(value[:1])
+		if err != nil || class < 1 || class > 5 {
+			return 0, 0, errors.Errorf("invalid HTTP status range \"%s\"", value)
+		}
+		return class * 100, class*100 + 99, nil
+	}
+	bounds := strings.SplitN(value, "-", 2)
+	min, err := strconv.Atoi(bounds[0])
+	if err != nil {
+		return 0, 0, errors.Errorf("invalid HTTP status range \"%s\"", value)
+	}
+	max := min
+	if len(bounds) == 2 {
+		if max, err = strconv.Atoi(bounds[1]); err != nil || max < min {
+			return 0, 0, errors.Errorf("invalid HTTP status range \"%s\"", value)
+		}
+	}
+	return min, max, nil
+}
+
+func (f *Filter) needsParsing() bool {
+	return f.MinLevel != "" || !f.Since.IsZero() || f.StatusMin != 0 || f.StatusMax != 0
+}
+
+// Match returns true if the record should be displayed
+func (f *Filter) Match(r *record) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.Sources) > 0 && !f.matchSource(r.name) {
+		return false
+	}
+	if f.Pattern != nil && !f.Pattern.MatchString(r.text()) {
+		return false
+	}
+	if !f.needsParsing() {
+		return true
+	}
+
+	entry := r.parse()
+	if entry == nil {
+		return false
+	}
+	if f.MinLevel != "" && humanlog.LevelSeverity(entry.Level) < humanlog.LevelSeverity(f.MinLevel) {
+		return false
+	}
+	if !f.Since.IsZero() && (entry.Time.IsZero() || entry.Time.Before(f.Since)) {
+		return false
+	}
+	if f.StatusMin != 0 || f.StatusMax != 0 {
+		if entry.Status == 0 || entry.Status < f.StatusMin || entry.Status > f.StatusMax {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *Filter) matchSource(source string) bool {
+	// worker replicas are named after their worker (messenger#2)
+	if i := strings.Index(source, "#"); i != -1 {
+		source = source[:i]
+	}
+	for _, s := range f.Sources {
+		if strings.EqualFold(s, source) || strings.EqualFold("Worker "+s, source) {
+			return true
+		}
+	}
+	return false
+}
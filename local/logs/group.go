@@ -0,0 +1,135 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package logs
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/symfony-cli/symfony-cli/humanlog"
+)
+
+// groupingDelay is how long to wait for continuation lines before a record
+// is considered complete
+const groupingDelay = 100 * time.Millisecond
+
+// PHP stack traces, Monolog multi-line exceptions, and indented lines
+var continuationRegexp = regexp.MustCompile(`^(?:\s|#\d+ |Stack trace:|\[stacktrace\]|\[previous exception\]|Next [\w\\]+|thrown in |"\})`)
+
+// record is a log entry made of a first line and its continuation lines
+type record struct {
+	name       string
+	file       string
+	lines      []string
+	receivedAt time.Time
+	// location is the time zone of the timestamps logged without one
+	location *time.Location
+}
+
+func isContinuation(line string) bool {
+	// FPM logs each line of a multi-line message separately
+	line = humanlog.PHPFPMLogLineRegexp.ReplaceAllString(line, "$1")
+	return continuationRegexp.MatchString(line)
+}
+
+func (r *record) text() string {
+	return strings.Join(r.lines, "\n")
+}
+
+// header returns the line to parse for the record; Monolog multi-line
+// records are only valid once their lines are joined back together
+func (r *record) header() string {
+	if len(r.lines) == 1 || humanlog.ParseInLocation([]byte(r.lines[0]), r.location) != nil {
+		return r.lines[0]
+	}
+	if joined := strings.Join(r.lines, `\n`); humanlog.ParseInLocation([]byte(joined), r.location) != nil {
+		return joined
+	}
+	return r.lines[0]
+}
+
+// parse returns the structured information of the record, if any
+func (r *record) parse() *humanlog.Entry {
+	return humanlog.ParseInLocation([]byte(r.header()), r.location)
+}
+
+// continuation returns the continuation lines without the FPM prefix
+func (r *record) continuation() []string {
+	lines := make([]string, 0, len(r.lines)-1)
+	for _, line := range r.lines[1:] {
+		lines = append(lines, humanlog.PHPFPMLogLineRegexp.ReplaceAllString(line, "$1"))
+	}
+	return lines
+}
+
+// group reads lines and calls fn for each complete record
+func (tailer *Tailer) group(fn func(*record) error) error {
+	pending := make(map[string]*record)
+	ticker := time.NewTicker(groupingDelay / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-tailer.stop:
+			return nil
+		case line := <-tailer.lines:
+			if line == nil {
+				continue
+			}
+			content := strings.TrimRight(line.line.Text, "\n")
+			key := line.name + "|" + line.file
+			if r, ok := pending[key]; ok {
+				if isContinuation(content) {
+					r.lines = append(r.lines, content)
+					r.receivedAt = time.Now()
+					continue
+				}
+				delete(pending, key)
+				if err := fn(r); err != nil {
+					return err
+				}
+			}
+			pending[key] = &record{
+				name:       line.name,
+				file:       line.file,
+				lines:      []string{content},
+				receivedAt: time.Now(),
+				location:   tailer.ZonelessLocation,
+			}
+		case <-ticker.C:
+			complete := []*record{}
+			for key, r := range pending {
+				if time.Since(r.receivedAt) >= groupingDelay {
+					complete = append(complete, r)
+					delete(pending, key)
+				}
+			}
+			sort.Slice(complete, func(i, j int) bool {
+				return complete[i].receivedAt.Before(complete[j].receivedAt)
+			})
+			for _, r := range complete {
+				if err := fn(r); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package logs
+
+import (
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type LogsSuite struct{}
+
+var _ = Suite(&LogsSuite{})
+
+func (s *LogsSuite) TestIsContinuation(c *C) {
+	c.Assert(isContinuation("Stack trace:"), Equals, true)
+	c.Assert(isContinuation("#0 {main}"), Equals, true)
+	c.Assert(isContinuation("  thrown in /app/index.php on line 3"), Equals, true)
+	c.Assert(isContinuation(`[12-Aug-2020 16:31:33] WARNING: [pool web] child 312 said into stderr: "#1 /app/index.php(3): foo()"`), Equals, true)
+	c.Assert(isContinuation("[2024-01-01 10:00:00] app.INFO: hello [] []"), Equals, false)
+	c.Assert(isContinuation(""), Equals, false)
+}
+
+func (s *LogsSuite) TestRecordHeader(c *C) {
+	r := &record{lines: []string{
+		`[2024-01-01 10:00:01] request.CRITICAL: Uncaught PHP Exception RuntimeException: "boom" {"exception":"[object] (RuntimeException(code: 0): boom at /app/src/X.php:10)`,
+		`[stacktrace]`,
+		`#0 {main}`,
+		`"} []`,
+	}}
+	entry := r.parse()
+	c.Assert(entry, NotNil)
+	c.Assert(entry.Level, Equals, "critical")
+	c.Assert(r.continuation(), HasLen, 3)
+}
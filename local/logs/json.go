@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package logs
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/symfony-cli/symfony-cli/humanlog"
+)
+
+// Record is the normalized representation of a log line in JSON output
+type Record struct {
+	Time *time.Time `json:"time,omitempty"`
+	// Level is empty when the line format is unknown
+	Level string `json:"level,omitempty"`
+	// Source is the name of the process or log the line comes from
+	Source string `json:"source"`
+	// Channel is the log channel, if any (request, doctrine, FPM, ...)
+	Channel string                     `json:"channel,omitempty"`
+	Message string                     `json:"message"`
+	Fields  map[string]json.RawMessage `json:"fields,omitempty"`
+	// Exception is the exception logged with the message, if any
+	Exception *humanlog.Exception `json:"exception,omitempty"`
+	File      string              `json:"file,omitempty"`
+	// Trace holds the continuation lines of multi-line records
+	Trace []string `json:"trace,omitempty"`
+}
+
+func newRecord(r *record, loc *time.Location) *Record {
+	content := r.header()
+	record := &Record{
+		Source:  r.name,
+		Message: content,
+		File:    r.file,
+	}
+	if len(r.lines) > 1 {
+		record.Trace = r.continuation()
+	}
+	entry := r.parse()
+	if entry == nil {
+		return record
+	}
+	if !entry.Time.IsZero() {
+		t := entry.Time
+		if loc != nil {
+			t = t.In(loc)
+		}
+		record.Time = &t
+	}
+	record.Level = entry.Level
+	record.Channel = entry.Source
+	record.Message = entry.Message
+	record.Exception = entry.Exception
+	if len(entry.Fields) > 0 {
+		record.Fields = make(map[string]json.RawMessage, len(entry.Fields))
+		for k, v := range entry.Fields {
+			// field values are JSON encoded, except for some raw PHP values
+			if json.Valid([]byte(v)) {
+				record.Fields[k] = json.RawMessage(v)
+			} else {
+				record.Fields[k], _ = json.Marshal(v)
+			}
+		}
+	}
+	return record
+}
+
+// tailJSON writes one Record per line; w should not interpret console tags
+func (tailer *Tailer) tailJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return tailer.group(func(r *record) error {
+		if !tailer.Filter.Match(r) {
+			return nil
+		}
+		return errors.WithStack(encoder.Encode(newRecord(r, tailer.Location)))
+	})
+}
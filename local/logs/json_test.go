@@ -0,0 +1,56 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package logs
+
+import (
+	"encoding/json"
+
+	"github.com/symfony-cli/symfony-cli/humanlog"
+	. "gopkg.in/check.v1"
+)
+
+func (s *LogsSuite) TestNewRecordKeepsException(c *C) {
+	r := &record{name: "app", lines: []string{
+		`[2024-01-01 10:00:01] request.CRITICAL: Uncaught PHP Exception RuntimeException: "boom" at /app/src/X.php line 10 {"exception":"[object] (RuntimeException(code: 0): boom at /app/src/X.php:10)","route":"home"} []`,
+	}}
+	record := newRecord(r, nil)
+	c.Assert(record.Level, Equals, "critical")
+	c.Assert(record.Exception, DeepEquals, &humanlog.Exception{
+		Class:   "RuntimeException",
+		Message: "boom",
+		Code:    "0",
+		File:    "/app/src/X.php",
+		Line:    10,
+	})
+	c.Assert(record.Fields, HasLen, 1)
+	c.Assert(string(record.Fields["route"]), Equals, `"home"`)
+
+	b, err := json.Marshal(record)
+	c.Assert(err, IsNil)
+	var decoded map[string]interface{}
+	c.Assert(json.Unmarshal(b, &decoded), IsNil)
+	c.Assert(decoded["exception"], DeepEquals, map[string]interface{}{
+		"class":   "RuntimeException",
+		"message": "boom",
+		"code":    "0",
+		"file":    "/app/src/X.php",
+		"line":    float64(10),
+	})
+}
@@ -20,6 +20,7 @@
 package logs
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"io"
@@ -27,19 +28,25 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/hpcloud/tail"
 	"github.com/pkg/errors"
 	"github.com/stoicperlman/fls"
 	"github.com/symfony-cli/symfony-cli/humanlog"
 	"github.com/symfony-cli/symfony-cli/inotify"
+	"github.com/symfony-cli/symfony-cli/local/logrotate"
 	"github.com/symfony-cli/symfony-cli/local/pid"
 	"github.com/symfony-cli/terminal"
 	realinotify "github.com/syncthing/notify"
 )
 
+// number of continuation lines displayed when a trace is collapsed
+const collapsedTraceLines = 5
+
 type namedLine struct {
 	name string
+	file string
 	line *tail.Line
 }
 
@@ -58,16 +65,36 @@ func (e logFileEvent) Sys() interface{} {
 }
 
 type Tailer struct {
-	Follow       bool
-	LinesNb      int64
-	NoHumanize   bool
-	AppLogs      []string
+	Follow     bool
+	LinesNb    int64
+	NoHumanize bool
+	AppLogs    []string
+	// AppLogPatterns are the glob patterns of the application log files,
+	// relative to the project directory (var/log/*.log by default)
+	AppLogPatterns []string
+	// AppEnv is the environment (APP_ENV) whose log is displayed as "Application"
+	AppEnv       string
 	NoAppLogs    bool
 	NoWorkerLogs bool
 	NoServerLogs bool
+	Filter       *Filter
+	// JSON outputs one normalized JSON object per line
+	JSON bool
+	// FullTraces displays all continuation lines of multi-line records
+	FullTraces bool
+	// Location is the time zone used to display timestamps (local by default)
+	Location *time.Location
+	// ZonelessLocation is the time zone of the timestamps logged without one
+	// (local by default)
+	ZonelessLocation *time.Location
+	// TimeFormat is how humanized timestamps are displayed
+	TimeFormat humanlog.TimeFormat
 
 	pidFileChan chan *pid.PidFile
 	lines       chan *namedLine
+	stop        chan struct{}
+	stopOnce    sync.Once
+	projectDir  string
 }
 
 func (tailer *Tailer) Watch(pidFile *pid.PidFile) error {
@@ -75,6 +102,8 @@ func (tailer *Tailer) Watch(pidFile *pid.PidFile) error {
 	// initialized soon enough
 	tailer.pidFileChan = make(chan *pid.PidFile)
 	tailer.lines = make(chan *namedLine, 100)
+	tailer.stop = make(chan struct{})
+	tailer.projectDir = pidFile.Dir
 
 	seenDirs := sync.Map{}
 	go func() {
@@ -101,145 +130,256 @@ func (tailer *Tailer) Watch(pidFile *pid.PidFile) error {
 			return err
 		}
 		watcherChan := make(chan inotify.EventInfo, 1)
-		if err := inotify.Watch(workerDir, watcherChan, inotify.Create); err != nil {
+		// pid files are written atomically by renaming a temporary file
+		if err := inotify.Watch(workerDir, watcherChan, inotify.Create, inotify.Rename); err != nil {
 			return errors.Wrap(err, "unable to watch the worker pid directory")
 		}
 		go func() {
 			for {
 				e := <-watcherChan
+				if !strings.HasSuffix(e.Path(), ".pid") {
+					continue
+				}
 				if _, ok := seenDirs.Load(e.Path()); ok {
 					continue
 				}
 				p, err := pid.Load(e.Path())
-				if err != nil {
-					terminal.Printfln("<warning>WARNING</> %s", err)
+				if os.IsNotExist(err) {
+					continue
+				} else if err != nil {
+					terminal.Eprintfln("<warning>WARNING</> %s", err)
 					continue
 				}
 				tailer.pidFileChan <- p
 			}
 		}()
-		for _, p := range pid.AllWorkers(pidFile.Dir) {
+		for _, p := range pid.AllWorkersAndScheduledTasks(pidFile.Dir) {
 			tailer.pidFileChan <- p
 		}
 	}
 
-	// Application log file (Symfony for now)
+	// Application log files (Symfony for now)
 	if !tailer.NoAppLogs {
-		applogs := tailer.AppLogs
-		if len(applogs) == 0 {
-			applogs = findApplicationLogFiles(pidFile.Dir)
+		if len(tailer.AppLogs) > 0 {
+			for _, applog := range tailer.AppLogs {
+				// Convert relative paths to absolute paths
+				absAppLog, err := filepath.Abs(applog)
+				if err != nil {
+					return errors.Wrapf(err, "unable to get absolute path for %s", applog)
+				}
+				if err := os.MkdirAll(filepath.Dir(absAppLog), 0755); err != nil {
+					return err
+				}
+				if err := tailer.watchAppLogs(absAppLog, true, &seenDirs); err != nil {
+					return err
+				}
+			}
+		} else {
+			patterns := tailer.AppLogPatterns
+			if len(patterns) == 0 {
+				patterns = defaultApplicationLogPatterns(pidFile.Dir)
+			}
+			for _, pattern := range patterns {
+				if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join(pidFile.Dir, pattern)
+				}
+				if _, err := os.Stat(filepath.Dir(pattern)); err != nil {
+					continue
+				}
+				if err := tailer.watchAppLogs(pattern, false, &seenDirs); err != nil {
+					return err
+				}
+			}
 		}
+	}
 
-		for _, applog := range applogs {
-			watcherChan := make(chan inotify.EventInfo, 1)
+	return nil
+}
 
-			// Convert relative paths to absolute paths
-			absAppLog, err := filepath.Abs(applog)
-			if err != nil {
-				return errors.Wrapf(err, "unable to get absolute path for %s", applog)
+// watchAppLogs tails the existing and future files matching the pattern (only
+// the file name can contain wildcards)
+func (tailer *Tailer) watchAppLogs(pattern string, explicit bool, seenDirs *sync.Map) error {
+	dir := filepath.Dir(pattern)
+	watcherChan := make(chan inotify.EventInfo, 1)
+	if err := inotify.Watch(dir, watcherChan, inotify.Create); err != nil {
+		return errors.Wrap(err, "unable to watch the applog directory")
+	}
+
+	// Evaluate possible symlinks in the applog path, this is needed because
+	// inotify will notify us on source path, and not the symlink path.
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return errors.Wrapf(err, "unable to evaluate symlinks for %s", dir)
+	}
+	pattern = filepath.Join(realDir, filepath.Base(pattern))
+	if explicit {
+		if _, err := os.Stat(pattern); err == nil {
+			if pattern, err = filepath.EvalSymlinks(pattern); err != nil {
+				return errors.Wrapf(err, "unable to evaluate symlinks for %s", pattern)
 			}
-			applog = absAppLog
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return errors.Wrapf(err, "unable to evaluate symlinks for %s", pattern)
+		}
+	}
+	existing, err := filepath.Glob(pattern)
+	if err != nil {
+		return errors.Wrapf(err, "invalid application log pattern %s", pattern)
+	}
 
-			dir := filepath.Dir(applog)
-			if err := os.MkdirAll(dir, 0755); err != nil {
-				return err
+	go func() {
+		for {
+			e := <-watcherChan
+			applog := e.Path()
+			if matched, _ := filepath.Match(pattern, applog); !matched {
+				continue
 			}
-			if err := inotify.Watch(dir, watcherChan, inotify.Create); err != nil {
-				return errors.Wrap(err, "unable to watch the applog directory")
+			if _, ok := seenDirs.Load(applog); ok {
+				continue
 			}
-
-			// Evaluate possible symlinks in the applog path, this is needed because
-			// inotify will notify us on source path, and not the symlink path.
-			if _, err := os.Stat(applog); err == nil {
-				realAppLog, err := filepath.EvalSymlinks(applog)
-				if err != nil {
-					return errors.Wrapf(err, "unable to evaluate symlinks for %s", applog)
+			seenDirs.Store(applog, true)
+			name := "Application"
+			if !explicit {
+				name = tailer.applicationLogName(applog)
+			}
+			go func() {
+				for _, line := range rotatedLines(applog, tailer.LinesNb) {
+					tailer.lines <- &namedLine{name: name, file: applog, line: line}
 				}
-				applog = realAppLog
-			} else if errors.Is(err, os.ErrNotExist) {
-				realDir, err := filepath.EvalSymlinks(dir)
+				tsf, err := tailFile(applog, tailer.Follow, tailer.LinesNb)
 				if err != nil {
-					return errors.Wrapf(err, "unable to evaluate symlinks for %s", dir)
+					terminal.Eprintfln("<warning>WARNING</> %s log file cannot be tailed: %s", applog, err)
+					return
 				}
-				applog = filepath.Join(realDir, filepath.Base(applog))
-			} else {
-				return errors.Wrapf(err, "unable to evaluate symlinks for %s", applog)
-			}
-
-			go func(applog string) {
-				for {
-					e := <-watcherChan
-					if e.Path() != applog {
-						continue
-					}
-					if _, ok := seenDirs.Load(applog); ok {
-						continue
-					}
-					seenDirs.Store(applog, true)
-					go func() {
-						tsf, err := tailFile(applog, tailer.Follow, tailer.LinesNb)
-						if err != nil {
-							terminal.Printfln("<warning>WARNING</> %s log file cannot be tailed: %s", applog, err)
-							return
-						}
-						for line := range tsf.Lines {
-							tailer.lines <- &namedLine{name: "Application", line: line}
-						}
-					}()
+				for line := range tsf.Lines {
+					tailer.lines <- &namedLine{name: name, file: applog, line: line}
 				}
-			}(applog)
-			watcherChan <- logFileEvent(applog)
+			}()
 		}
+	}()
+	for _, applog := range existing {
+		watcherChan <- logFileEvent(applog)
 	}
-
 	return nil
 }
 
+// applicationLogName returns "Application" for the log of the current
+// environment and the file name for other logs (channels, environments)
+func (tailer *Tailer) applicationLogName(applog string) string {
+	env := tailer.AppEnv
+	if env == "" {
+		env = "dev"
+	}
+	name := strings.TrimSuffix(filepath.Base(applog), filepath.Ext(applog))
+	if name == env {
+		return "Application"
+	}
+	return name
+}
+
 func (tailer *Tailer) Tail(w io.Writer) error {
+	if tailer.JSON {
+		return tailer.tailJSON(w)
+	}
+
 	var humanizer *humanlog.Handler
 	if !tailer.NoHumanize {
 		humanizer = humanlog.NewHandler(&humanlog.Options{
-			SkipUnchanged: true,
-			WithSource:    true,
+			SkipUnchanged:    true,
+			WithSource:       true,
+			Location:         tailer.Location,
+			ZonelessLocation: tailer.ZonelessLocation,
+			TimeFormat:       tailer.TimeFormat,
+			ProjectDir:       tailer.projectDir,
 		})
 	}
 
 	var buf bytes.Buffer
-	for {
-		line := <-tailer.lines
-		if line == nil {
-			continue
+	return tailer.group(func(r *record) error {
+		if !tailer.Filter.Match(r) {
+			return nil
 		}
 		buf.Reset()
-		fmt.Fprintf(&buf, "[<info>%-11s</>] ", line.name)
-		content := strings.TrimRight(line.line.Text, "\n")
 		if humanizer == nil {
-			fmt.Fprintln(&buf, content)
+			for _, line := range r.lines {
+				fmt.Fprintf(&buf, "[<info>%-11s</>] ", r.name)
+				fmt.Fprintln(&buf, line)
+			}
 		} else {
-			buf.Write(humanizer.Prettify([]byte(content)))
+			header := r.header()
+			fmt.Fprintf(&buf, "[<info>%-11s</>] ", r.name)
+			buf.Write(humanizer.Prettify([]byte(header)))
 			buf.Write([]byte("\n"))
+			// joined records are rendered as a whole by the humanizer
+			if header == r.lines[0] {
+				tailer.writeContinuation(&buf, r)
+			}
 		}
-		w.Write(buf.Bytes())
+		_, err := w.Write(buf.Bytes())
+		return err
+	})
+}
+
+// writeContinuation writes the continuation lines of a record (stack traces),
+// collapsed unless FullTraces is set
+func (tailer *Tailer) writeContinuation(buf *bytes.Buffer, r *record) {
+	lines := r.continuation()
+	hidden := 0
+	if !tailer.FullTraces && len(lines) > collapsedTraceLines {
+		hidden = len(lines) - collapsedTraceLines
+		lines = lines[:collapsedTraceLines]
+	}
+	for _, line := range lines {
+		buf.WriteString(strings.Repeat(" ", 16))
+		buf.Write(terminal.Escape([]byte(strings.TrimSpace(line))))
+		buf.Write([]byte("\n"))
+	}
+	if hidden > 0 {
+		fmt.Fprintf(buf, "%s<comment>... %d more lines (use --full-traces to display them)</>\n", strings.Repeat(" ", 16), hidden)
 	}
 }
 
-func (tailer Tailer) WatchAdditionalPidFile(file *pid.PidFile) {
+// WatchStream adds the lines read from r (like the output of a remote log
+// command) to the tailed logs; it must be called after Watch
+func (tailer *Tailer) WatchStream(name string, r io.Reader) {
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			tailer.lines <- &namedLine{name: name, line: &tail.Line{Text: scanner.Text()}}
+		}
+		if err := scanner.Err(); err != nil {
+			terminal.Eprintfln("<warning>WARNING</> %s logs cannot be read: %s", name, err)
+		}
+	}()
+}
+
+// Stop makes Tail return
+func (tailer *Tailer) Stop() {
+	tailer.stopOnce.Do(func() {
+		close(tailer.stop)
+	})
+}
+
+func (tailer *Tailer) WatchAdditionalPidFile(file *pid.PidFile) {
 	tailer.pidFileChan <- file
 }
 
 func tailLogFile(p *pid.PidFile, lines chan *namedLine, follow bool, nblines int64) {
 	if err := p.WaitForLogs(); err != nil {
-		terminal.Printfln("<warning>WARNING</> %s log file cannot be tailed: %s", p.String(), err)
+		terminal.Eprintfln("<warning>WARNING</> %s log file cannot be tailed: %s", p.String(), err)
 		return
 	}
+	for _, line := range rotatedLines(p.LogFile(), nblines) {
+		lines <- &namedLine{name: p.ShortName(), file: p.LogFile(), line: line}
+	}
 	t, err := tailFile(p.LogFile(), follow, nblines)
 	if err != nil {
-		terminal.Printfln("<warning>WARNING</> %s log file cannot be tailed: %s", p.String(), err)
+		terminal.Eprintfln("<warning>WARNING</> %s log file cannot be tailed: %s", p.String(), err)
 		return
 	}
-	terminal.Printfln("Following <info>%s</info> log file (%s)", p.String(), p.LogFile())
+	terminal.Eprintfln("Following <info>%s</info> log file (%s)", p.String(), p.LogFile())
 	for line := range t.Lines {
-		lines <- &namedLine{name: p.ShortName(), line: line}
+		lines <- &namedLine{name: p.ShortName(), file: p.LogFile(), line: line}
 	}
 }
 
@@ -262,22 +402,58 @@ func tailFile(filename string, follow bool, nblines int64) (*tail.Tail, error) {
 	})
 }
 
-// find the application log file(s) (only Symfony is supported for now)
-func findApplicationLogFiles(projectDir string) []string {
+// rotatedLines returns the lines to read from rotated log files when the
+// current log file has less than nblines lines
+func rotatedLines(filename string, nblines int64) []*tail.Line {
+	if nblines <= 0 || len(logrotate.Backups(filename)) == 0 {
+		return nil
+	}
+	missing := nblines - countLines(filename)
+	if missing <= 0 {
+		return nil
+	}
+	texts, err := logrotate.ReadBackupLines(filename, missing)
+	if err != nil {
+		terminal.Eprintfln("<warning>WARNING</> unable to read rotated log files for %s: %s", filename, err)
+		return nil
+	}
+	lines := make([]*tail.Line, 0, len(texts))
+	for _, text := range texts {
+		lines = append(lines, &tail.Line{Text: text})
+	}
+	return lines
+}
+
+func countLines(filename string) int64 {
+	f, err := os.Open(filename)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	var count int64
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := f.Read(buf)
+		count += int64(bytes.Count(buf[:n], []byte{'\n'}))
+		if err != nil {
+			return count
+		}
+	}
+}
+
+// default patterns for the application log files (only Symfony is supported for now)
+func defaultApplicationLogPatterns(projectDir string) []string {
 	subdirs := []string{
 		filepath.Join("var", "log"),
 		filepath.Join("var", "logs"),
 		filepath.Join("app", "logs"),
 	}
-	// FIXME
-	env := "dev"
-	files := []string{}
+	patterns := []string{}
 	for _, subdir := range subdirs {
-		applog := filepath.Join(projectDir, subdir, env+".log")
-		if _, err := os.Stat(applog); err != nil {
+		if _, err := os.Stat(filepath.Join(projectDir, subdir)); err != nil {
 			continue
 		}
-		files = append(files, applog)
+		patterns = append(patterns, filepath.Join(subdir, "*.log"))
 	}
-	return files
+	return patterns
 }
@@ -164,7 +164,7 @@ func (p *Server) Start(ctx context.Context, pidFile *pid.PidFile) (*pid.PidFile,
 	if err != nil {
 		return phpPidFile, nil, err
 	}
-	runner.AlwaysRestartOnExit = true
+	runner.RestartPolicy = local.RestartAlways
 	runner.BuildCmdHook = func(cmd *exec.Cmd) error {
 		cmd.Dir = workingDir
 
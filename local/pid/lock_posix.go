@@ -22,12 +22,15 @@
 
 package pid
 
-import "syscall"
+import (
+	"os"
+	"syscall"
+)
 
-func kill(pid int) error {
-	pgid, err := syscall.Getpgid(pid)
-	if err != nil {
-		return err
-	}
-	return syscall.Kill(-pgid, syscall.SIGTERM)
+func lockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
 }
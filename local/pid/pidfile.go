@@ -29,14 +29,20 @@ import (
 	"path/filepath"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/mitchellh/go-homedir"
 	"github.com/pkg/errors"
 	"github.com/symfony-cli/symfony-cli/inotify"
+	"github.com/symfony-cli/symfony-cli/local/logrotate"
+	"github.com/symfony-cli/symfony-cli/local/process"
 	"github.com/symfony-cli/symfony-cli/local/projects"
 	"github.com/symfony-cli/symfony-cli/util"
 )
 
+// DefaultStopTimeout is the time given to a process to stop before killing it
+const DefaultStopTimeout = 10 * time.Second
+
 type PidFile struct {
 	Dir        string   `json:"dir"`
 	Watched    []string `json:"watch"`
@@ -45,27 +51,52 @@ type PidFile struct {
 	Scheme     string   `json:"scheme"`
 	Args       []string `json:"args"`
 	CustomName string   `json:"name"`
+	Replica    int      `json:"replica,omitempty"`
+
+	StopSignal  string        `json:"stop_signal,omitempty"`
+	StopTimeout time.Duration `json:"stop_timeout,omitempty"`
+
+	// StartTime identifies when the process started to detect PID reuse
+	StartTime int64 `json:"start_time,omitempty"`
+	// Restarts is the number of times the command has been restarted
+	Restarts int `json:"restarts,omitempty"`
+	// LastExitCode is the exit code of the last run of the command (-1 when
+	// it was killed by a signal)
+	LastExitCode *int `json:"last_exit_code,omitempty"`
+	// Scheduled marks the pid file of a scheduled task, which is kept
+	// between two runs so that its logs can be followed
+	Scheduled bool `json:"scheduled,omitempty"`
 
 	path string
 }
 
 func New(dir string, args []string) *PidFile {
+	return NewReplica(dir, args, 0)
+}
+
+// NewReplica returns the pid file of a replica of a worker command; replicas
+// are numbered from 1 and the first one shares the pid file of the command
+// when not replicated.
+func NewReplica(dir string, args []string, replica int) *PidFile {
 	var path string
 	command := strings.Join(args, " ")
 	if args == nil {
 		// server or proxy
 		path = filepath.Join(util.GetHomeDir(), "var", name(dir)+".pid")
-	} else {
+	} else if replica > 1 {
 		// workers are stored in a sub-directory
+		path = filepath.Join(util.GetHomeDir(), "var", name(dir), name(fmt.Sprintf("%s#%d", command, replica))+".pid")
+	} else {
 		path = filepath.Join(util.GetHomeDir(), "var", name(dir), name(command)+".pid")
 	}
 	// we need to load the existing file if there is one
 	p, err := Load(path)
 	if err != nil {
 		p = &PidFile{
-			Dir:  dir,
-			Args: args,
-			path: path,
+			Dir:     dir,
+			Args:    args,
+			Replica: replica,
+			path:    path,
 		}
 	}
 	return p
@@ -105,6 +136,9 @@ func (p *PidFile) ShortName() string {
 	if len(p.Args) == 0 {
 		return "Web Server"
 	}
+	if p.Replica > 1 {
+		return fmt.Sprintf("Worker %s#%d", p.Args[0], p.Replica)
+	}
 	return "Worker " + p.Args[0]
 }
 
@@ -119,7 +153,7 @@ func (p *PidFile) WaitForPid() <-chan error {
 		return ch
 	}
 
-	if err := inotify.Watch(filepath.Dir(p.path), watcherChan, inotify.Create); err != nil {
+	if err := inotify.Watch(filepath.Dir(p.path), watcherChan, inotify.Create, inotify.Rename); err != nil {
 		ch <- err
 		return ch
 	}
@@ -174,6 +208,9 @@ func (p *PidFile) LogFile() string {
 	if p.CustomName != "" {
 		return filepath.Join(p.WorkerLogDir(), name(p.CustomName)+".log")
 	}
+	if p.Replica > 1 {
+		return filepath.Join(p.WorkerLogDir(), name(fmt.Sprintf("%s#%d", p.Command(), p.Replica))+".log")
+	}
 	return filepath.Join(p.WorkerLogDir(), name(p.Command())+".log")
 }
 
@@ -201,16 +238,23 @@ func (p *PidFile) LogReader() (io.ReadCloser, error) {
 	return r, nil
 }
 
+// LogWriter returns a writer to a fresh log file, the previous content being
+// rotated according to the global log rotation configuration
 func (p *PidFile) LogWriter() (io.WriteCloser, error) {
-	logFile := p.LogFile()
-	if err := os.MkdirAll(filepath.Dir(logFile), 0755); err != nil {
-		return nil, err
-	}
-	w, err := os.OpenFile(logFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	return p.openLogFile(true)
+}
+
+// LogAppender returns a writer appending to the log file instead of truncating it
+func (p *PidFile) LogAppender() (io.WriteCloser, error) {
+	return p.openLogFile(false)
+}
+
+func (p *PidFile) openLogFile(rotate bool) (io.WriteCloser, error) {
+	config, err := logrotate.LoadConfig(util.GetHomeDir())
 	if err != nil {
 		return nil, err
 	}
-	return w, nil
+	return logrotate.Open(p.LogFile(), config, rotate)
 }
 
 func (p *PidFile) Binary() string {
@@ -220,23 +264,50 @@ func (p *PidFile) Binary() string {
 	return p.Args[0]
 }
 
+// AllWorkers returns the pid files of the running commands of a project
 func AllWorkers(dir string) []*PidFile {
-	return doAll(filepath.Join(util.GetHomeDir(), "var", name(dir)))
+	return doAll(filepath.Join(util.GetHomeDir(), "var", name(dir)), false)
+}
+
+// AllWorkersAndScheduledTasks returns the pid files of the running commands
+// of a project and of its scheduled tasks, even between two runs
+func AllWorkersAndScheduledTasks(dir string) []*PidFile {
+	return doAll(filepath.Join(util.GetHomeDir(), "var", name(dir)), true)
 }
 
-// Remove a pidfile
+// Remove a pidfile; the log file is left untouched, it is rotated when the
+// process is started again
 func (p *PidFile) Remove() error {
-	for _, file := range []string{p.LogFile(), p.PidFile()} {
-		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
-			return errors.WithStack(err)
-		}
-		// DO NOT remove empty dirs (as it makes inotify fail)
+	if _, err := os.Stat(p.PidFile()); os.IsNotExist(err) {
+		return nil
+	}
+	unlock, err := p.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := os.Remove(p.PidFile()); err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
 	}
+	// removed while holding the lock, see lock()
+	os.Remove(p.lockFile())
+	// DO NOT remove empty dirs (as it makes inotify fail)
 	return nil
 }
 
 // Write writes a pidfile
 func (p *PidFile) Write(pid, port int, scheme string) error {
+	if err := os.MkdirAll(filepath.Dir(p.path), 0755); err != nil && !os.IsExist(err) {
+		return err
+	}
+
+	// the lock prevents concurrent commands from starting the same process
+	unlock, err := p.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	oldPid, err := Load(p.PidFile())
 	if err == nil && oldPid.IsRunning() {
 		return errors.Errorf("Process is already running under PID %d", oldPid.Pid)
@@ -245,26 +316,122 @@ func (p *PidFile) Write(pid, port int, scheme string) error {
 	p.Pid = pid
 	p.Port = port
 	p.Scheme = scheme
+	p.StartTime = 0
+	if pid != 0 {
+		if startTime, err := process.StartTime(pid); err == nil {
+			p.StartTime = startTime
+		}
+	}
 
-	if err := os.MkdirAll(filepath.Dir(p.path), 0755); err != nil && !os.IsExist(err) {
+	return p.save()
+}
+
+// Update writes the changes made to the pidfile of a running process (like
+// its restart count)
+func (p *PidFile) Update() error {
+	unlock, err := p.lock()
+	if err != nil {
 		return err
 	}
+	defer unlock()
 
+	return p.save()
+}
+
+func (p *PidFile) save() error {
 	b, err := json.MarshalIndent(p, "", "    ")
 	if err != nil {
 		return err
 	}
 
-	return ioutil.WriteFile(p.path, b, 0644)
+	// write to a temporary file first as readers must never see a partially written file
+	tmp, err := ioutil.TempFile(filepath.Dir(p.path), "."+filepath.Base(p.path)+".*.tmp")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return errors.WithStack(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(os.Rename(tmp.Name(), p.path))
+}
+
+// lock acquires an exclusive lock on the pidfile, released by calling the
+// returned function
+func (p *PidFile) lock() (func(), error) {
+	for {
+		f, err := os.OpenFile(p.lockFile(), os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if err := lockFile(f); err != nil {
+			f.Close()
+			return nil, errors.Wrapf(err, "unable to lock %s", p.path)
+		}
+		// the lock file might have been removed by Remove() while waiting
+		// for the lock, the lock is then held on a stale file
+		locked, err1 := f.Stat()
+		current, err2 := os.Stat(p.lockFile())
+		if err1 == nil && err2 == nil && os.SameFile(locked, current) {
+			return func() {
+				unlockFile(f)
+				f.Close()
+			}, nil
+		}
+		unlockFile(f)
+		f.Close()
+	}
+}
+
+func (p *PidFile) lockFile() string {
+	return p.path + ".lock"
 }
 
-// Stop kills the current process
+// Stop stops the current process and its children, they are killed if
+// still running after the stop timeout
 func (p *PidFile) Stop() error {
 	if p.Pid == 0 {
 		return nil
 	}
 	defer p.Remove()
-	return kill(p.Pid)
+
+	sig, timeout := p.StopSettings()
+	if err := process.SignalGroup(p.Pid, sig); err != nil {
+		return err
+	}
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); {
+		if !p.IsRunning() {
+			// make sure no child of the process is left behind
+			process.SignalGroup(p.Pid, os.Kill)
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return process.SignalGroup(p.Pid, os.Kill)
+}
+
+// StopSettings returns the signal to send to stop the process and the time
+// to wait for it to exit before killing it
+func (p *PidFile) StopSettings() (os.Signal, time.Duration) {
+	var sig os.Signal = syscall.SIGTERM
+	if p.StopSignal != "" {
+		if s, err := process.ParseSignal(p.StopSignal); err == nil {
+			sig = s
+		}
+	}
+	timeout := p.StopTimeout
+	if timeout <= 0 {
+		timeout = DefaultStopTimeout
+	}
+	return sig, timeout
 }
 
 func ToConfiguredProjects() (map[string]*projects.ConfiguredProject, error) {
@@ -273,7 +440,7 @@ func ToConfiguredProjects() (map[string]*projects.ConfiguredProject, error) {
 	if err != nil {
 		userHomeDir = ""
 	}
-	for _, pid := range doAll(filepath.Join(util.GetHomeDir(), "var")) {
+	for _, pid := range doAll(filepath.Join(util.GetHomeDir(), "var"), false) {
 		if !pid.IsRunning() {
 			continue
 		}
@@ -295,11 +462,17 @@ func (p *PidFile) IsRunning() bool {
 	if p.Pid == 0 {
 		return false
 	}
-	process, err := os.FindProcess(p.Pid)
+	if p.StartTime != 0 {
+		// the PID has been reused by another process
+		if startTime, err := process.StartTime(p.Pid); err == nil && startTime != p.StartTime {
+			return false
+		}
+	}
+	proc, err := os.FindProcess(p.Pid)
 	if err != nil {
 		return false
 	}
-	err = process.Signal(syscall.Signal(0))
+	err = proc.Signal(syscall.Signal(0))
 	if err != nil && err.Error() == "no such process" {
 		return false
 	}
@@ -319,7 +492,7 @@ func name(dir string) string {
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
-func doAll(dir string) []*PidFile {
+func doAll(dir string, withScheduledTasks bool) []*PidFile {
 	pidFiles := []*PidFile{}
 	filepath.Walk(dir, func(p string, f os.FileInfo, err error) error {
 		if err != nil {
@@ -341,11 +514,17 @@ func doAll(dir string) []*PidFile {
 		if err := json.Unmarshal(contents, &pidFile); err != nil {
 			return nil
 		}
-		if strings.Contains(pidFile.Dir, "__proxy__") {
+		if strings.Contains(pidFile.Dir, "__proxy__") || strings.Contains(pidFile.Dir, "__supervisor__") {
 			return nil
 		}
 		pidFile.path = p
 		if !pidFile.IsRunning() {
+			if pidFile.Scheduled {
+				if withScheduledTasks {
+					pidFiles = append(pidFiles, pidFile)
+				}
+				return nil
+			}
 			pidFile.Remove()
 			return nil
 		}
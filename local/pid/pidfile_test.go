@@ -0,0 +1,79 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package pid
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type PidFileSuite struct{}
+
+var _ = Suite(&PidFileSuite{})
+
+func (s *PidFileSuite) TestAllWorkersKeepsScheduledTasks(c *C) {
+	home, err := ioutil.TempDir("", "symfony-pid")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(home)
+	oldHome := os.Getenv("HOME")
+	homedir.Reset()
+	os.Setenv("HOME", home)
+	defer func() {
+		os.Setenv("HOME", oldHome)
+		homedir.Reset()
+	}()
+
+	cron := New("/project", []string{"php", "bin/console", "app:cleanup"})
+	cron.CustomName = "cron:cleanup"
+	cron.Scheduled = true
+	c.Assert(cron.Write(0, 0, ""), IsNil)
+
+	stale := New("/project", []string{"php", "bin/console", "messenger:consume"})
+	c.Assert(stale.Write(0, 0, ""), IsNil)
+	c.Assert(os.MkdirAll(filepath.Dir(stale.LogFile()), 0755), IsNil)
+	c.Assert(ioutil.WriteFile(stale.LogFile(), []byte("last run\n"), 0644), IsNil)
+
+	c.Assert(AllWorkers("/project"), HasLen, 0)
+	// cleaning up stale pid files must not rotate their logs
+	logs, err := ioutil.ReadDir(filepath.Dir(stale.LogFile()))
+	c.Assert(err, IsNil)
+	c.Assert(logs, HasLen, 1)
+	contents, err := ioutil.ReadFile(stale.LogFile())
+	c.Assert(err, IsNil)
+	c.Assert(string(contents), Equals, "last run\n")
+	_, err = os.Stat(cron.PidFile())
+	c.Assert(err, IsNil)
+	_, err = os.Stat(stale.PidFile())
+	c.Assert(os.IsNotExist(err), Equals, true)
+	_, err = os.Stat(stale.PidFile() + ".lock")
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	pidFiles := AllWorkersAndScheduledTasks("/project")
+	c.Assert(pidFiles, HasLen, 1)
+	c.Assert(pidFiles[0].CustomName, Equals, "cron:cleanup")
+	c.Assert(pidFiles[0].LogFile(), Equals, cron.LogFile())
+}
@@ -26,6 +26,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/pkg/errors"
 	"github.com/symfony-cli/terminal"
 	yaml "gopkg.in/yaml.v2"
 )
@@ -59,12 +60,53 @@ type ApplicationsDotYaml []struct {
 type LocalWorker struct {
 }
 
+type LocalCron struct {
+	Spec string `yaml:"spec"`
+	// Cmd is the legacy way to define the command
+	Cmd      string `yaml:"cmd"`
+	Commands struct {
+		Start string `yaml:"start"`
+	} `yaml:"commands"`
+}
+
+// Command returns the shell command run by the cron
+func (c LocalCron) Command() string {
+	if c.Commands.Start != "" {
+		return c.Commands.Start
+	}
+	return c.Cmd
+}
+
+// HookNames lists the hooks in the order they are run on deployment
+var HookNames = []string{"build", "deploy", "post_deploy"}
+
+type LocalHooks struct {
+	Build      string `yaml:"build"`
+	Deploy     string `yaml:"deploy"`
+	PostDeploy string `yaml:"post_deploy"`
+}
+
+// Get returns the script of the given hook
+func (h LocalHooks) Get(name string) (string, error) {
+	switch name {
+	case "build":
+		return h.Build, nil
+	case "deploy":
+		return h.Deploy, nil
+	case "post_deploy":
+		return h.PostDeploy, nil
+	}
+	return "", errors.Errorf("unknown hook \"%s\" (must be one of %s)", name, strings.Join(HookNames, ", "))
+}
+
 type LocalApplication struct {
 	DefinitionFile string                 `yaml:"-"`
 	LocalRootDir   string                 `yaml:"-"`
 	Name           string                 `yaml:"name"`
 	Type           string                 `yaml:"type"`
 	Workers        map[string]LocalWorker `yaml:"workers"`
+	Crons          map[string]LocalCron   `yaml:"crons"`
+	Hooks          LocalHooks             `yaml:"hooks"`
 }
 
 // ApplicationInterface interface
@@ -0,0 +1,64 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package process
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ParseSignal converts a signal name (like "SIGTERM" or "TERM") to a signal,
+// only interrupting or killing a process is supported on Windows
+func ParseSignal(name string) (os.Signal, error) {
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	switch name {
+	case "SIGINT":
+		return os.Interrupt, nil
+	case "SIGHUP", "SIGQUIT", "SIGKILL", "SIGTERM":
+		return os.Kill, nil
+	}
+	return nil, errors.Errorf("unsupported signal \"%s\"", name)
+}
+
+// SetProcessGroup is a no-op on Windows as process trees are killed via taskkill
+func SetProcessGroup(cmd *exec.Cmd) {
+}
+
+// SignalGroup sends a signal to the given process, killing it with all its
+// children when the signal cannot be delivered
+func SignalGroup(pid int, sig os.Signal) error {
+	if sig != os.Kill {
+		p, err := os.FindProcess(pid)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if err := p.Signal(sig); err == nil {
+			return nil
+		}
+	}
+	return errors.WithStack(exec.Command("CMD", "/C", "TASKKILL", "/F", "/T", "/PID", strconv.Itoa(pid)).Run())
+}
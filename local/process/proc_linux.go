@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package process
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// clockTicks is the number of clock ticks per second used in /proc (USER_HZ)
+const clockTicks = 100
+
+// StartTime returns a value identifying when the process started (in clock
+// ticks since boot), used to detect that a PID has been reused
+func StartTime(pid int) (int64, error) {
+	fields, err := procStat(pid)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(fields[19], 10, 64)
+}
+
+// GetUsage returns the resource usage of a process
+func GetUsage(pid int) (*Usage, error) {
+	fields, err := procStat(pid)
+	if err != nil {
+		return nil, err
+	}
+	var values [4]float64
+	// utime, stime, starttime, and rss
+	for i, index := range []int{11, 12, 19, 21} {
+		if values[i], err = strconv.ParseFloat(fields[index], 64); err != nil {
+			return nil, errors.Wrapf(err, "unable to parse the stat of process %d", pid)
+		}
+	}
+
+	contents, err := ioutil.ReadFile("/proc/uptime")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	systemUptime, err := strconv.ParseFloat(strings.Fields(string(contents))[0], 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse the system uptime")
+	}
+
+	usage := &Usage{
+		RSS:    uint64(values[3]) * uint64(os.Getpagesize()),
+		Uptime: time.Duration((systemUptime - values[2]/clockTicks) * float64(time.Second)),
+	}
+	if elapsed := usage.Uptime.Seconds(); elapsed > 0 {
+		usage.CPU = (values[0] + values[1]) / clockTicks / elapsed * 100
+	}
+	return usage, nil
+}
+
+// procStat returns the fields of /proc/<pid>/stat after the command name
+func procStat(pid int) ([]string, error) {
+	contents, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// the command name is between parentheses and can contain spaces
+	stat := string(contents)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	// fields are numbered from the state, the 3rd field of the file
+	if len(fields) < 22 {
+		return nil, errors.Errorf("unable to parse the stat of process %d", pid)
+	}
+	return fields, nil
+}
@@ -17,19 +17,15 @@
  * along with this program. If not, see <http://www.gnu.org/licenses/>.
  */
 
-package pid
+package process
 
-import (
-	"os"
+import "time"
 
-	"github.com/pkg/errors"
-)
-
-func kill(pid int) error {
-	p, err := os.FindProcess(pid)
-	if err != nil {
-		return errors.WithStack(err)
-	}
-
-	return errors.WithStack(p.Kill())
+// Usage is the resource usage of a process
+type Usage struct {
+	// CPU is the percentage of CPU time used since the process started
+	CPU float64
+	// RSS is the resident memory size in bytes
+	RSS    uint64
+	Uptime time.Duration
 }
@@ -0,0 +1,80 @@
+//go:build !linux
+// +build !linux
+
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package process
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// GetUsage returns the resource usage of a process
+func GetUsage(pid int) (*Usage, error) {
+	out, err := exec.Command("ps", "-o", "pcpu=,rss=,etime=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 3 {
+		return nil, errors.Errorf("unable to parse the usage of process %d", pid)
+	}
+
+	usage := &Usage{}
+	if usage.CPU, err = strconv.ParseFloat(strings.Replace(fields[0], ",", ".", 1), 64); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	rss, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	usage.RSS = rss * 1024
+	if usage.Uptime, err = parseElapsedTime(fields[2]); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// parseElapsedTime parses the [[dd-]hh:]mm:ss format used by ps
+func parseElapsedTime(etime string) (time.Duration, error) {
+	var days int
+	if i := strings.Index(etime, "-"); i != -1 {
+		d, err := strconv.Atoi(etime[:i])
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		days = d
+		etime = etime[i+1:]
+	}
+	var seconds int
+	for _, part := range strings.Split(etime, ":") {
+		v, err := strconv.Atoi(part)
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		seconds = seconds*60 + v
+	}
+	return time.Duration(days)*24*time.Hour + time.Duration(seconds)*time.Second, nil
+}
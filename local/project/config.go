@@ -23,10 +23,18 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/symfony-cli/console"
+	"github.com/symfony-cli/symfony-cli/humanlog"
+	"github.com/symfony-cli/symfony-cli/local"
+	"github.com/symfony-cli/symfony-cli/local/cron"
+	"github.com/symfony-cli/symfony-cli/local/process"
 	"gopkg.in/yaml.v2"
 )
 
@@ -51,13 +59,80 @@ type FileConfig struct {
 	Proxy struct {
 		Domains []string `yaml:"domains"`
 	} `yaml:"proxy"`
-	HTTP    *Config            `yaml:"http"`
-	Workers map[string]*Worker `yaml:"workers"`
+	HTTP      *Config              `yaml:"http"`
+	Workers   map[string]*Worker   `yaml:"workers"`
+	Schedules map[string]*Schedule `yaml:"schedules"`
+	Logs      *Logs                `yaml:"logs"`
+}
+
+// Logs configures the application logs displayed by server:log
+type Logs struct {
+	// Files are glob patterns relative to the project directory, only the
+	// file name can contain wildcards (var/log/*.log by default)
+	Files []string `yaml:"files"`
+	// Formats are custom log formats, tried before the built-in ones
+	Formats map[string]*LogFormat `yaml:"formats"`
+}
+
+// LogFormat is a custom log format parsed with a regular expression
+type LogFormat struct {
+	// Regex must have a "message" named group; "time", "level", and "source"
+	// groups are used when present, other named groups become fields
+	Regex string `yaml:"regex"`
+	// TimeFormat is the Go layout of the "time" group
+	TimeFormat string `yaml:"time_format"`
+}
+
+// Parsers returns the parsers of the custom log formats, sorted by name
+func (l *Logs) Parsers() ([]humanlog.Parser, error) {
+	names := make([]string, 0, len(l.Formats))
+	for name := range l.Formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parsers := make([]humanlog.Parser, 0, len(names))
+	for _, name := range names {
+		format := l.Formats[name]
+		if format == nil || format.Regex == "" {
+			return nil, errors.Errorf("The \"%s\" log format in \".symfony.local.yaml\" must define a regex.", name)
+		}
+		p, err := humanlog.NewRegexpParser(name, format.Regex, format.TimeFormat)
+		if err != nil {
+			return nil, errors.Wrap(err, "The \".symfony.local.yaml\" logs configuration is invalid")
+		}
+		parsers = append(parsers, p)
+	}
+	return parsers, nil
+}
+
+// Schedule is a command run periodically while the local web server is running
+type Schedule struct {
+	// Spec is a cron spec like "*/5 * * * *" or "@hourly"
+	Spec string   `yaml:"spec"`
+	Cmd  []string `yaml:"cmd"`
 }
 
 type Worker struct {
 	Cmd   []string `yaml:"cmd"`
 	Watch []string `yaml:"watch"`
+	// WatchOptions filters and debounces the changes in the watched paths
+	WatchOptions *local.WatchOptions   `yaml:"watch_options"`
+	DependsOn    []string              `yaml:"depends_on"`
+	Readiness    *local.ReadinessProbe `yaml:"readiness"`
+	// Replicas is the number of instances of the command to run (1 by default)
+	Replicas int `yaml:"replicas"`
+	// Env is added to the project environment variables
+	Env map[string]string `yaml:"env"`
+	// Dir is the working directory, relative to the project directory
+	Dir             string              `yaml:"dir"`
+	Restart         local.RestartPolicy `yaml:"restart"`
+	MaxRetries      int                 `yaml:"max_retries"`
+	RestartDelay    time.Duration       `yaml:"restart_delay"`
+	MaxRestartDelay time.Duration       `yaml:"max_restart_delay"`
+	// StopSignal is sent to stop the worker (SIGTERM by default) and
+	// StopTimeout is the time to wait before killing it
+	StopSignal  string        `yaml:"stop_signal"`
+	StopTimeout time.Duration `yaml:"stop_timeout"`
 }
 
 func NewConfigFromContext(c *console.Context, projectDir string) (*Config, *FileConfig, error) {
@@ -112,6 +187,19 @@ func NewConfigFromContext(c *console.Context, projectDir string) (*Config, *File
 	return config, fileConfig, nil
 }
 
+// NewFileConfig reads the .symfony.local.yaml file of a project, it returns
+// an empty configuration when the file does not exist
+func NewFileConfig(projectDir string) (*FileConfig, error) {
+	fileConfig, err := newConfigFromFile(filepath.Join(projectDir, ".symfony.local.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	if fileConfig == nil {
+		fileConfig = &FileConfig{Workers: make(map[string]*Worker)}
+	}
+	return fileConfig, nil
+}
+
 // Should only be used when for customers
 func newConfigFromFile(configFile string) (*FileConfig, error) {
 	if _, err := os.Stat(configFile); err != nil {
@@ -132,6 +220,16 @@ func newConfigFromFile(configFile string) (*FileConfig, error) {
 		return nil, err
 	}
 
+	if err := fileConfig.parseSchedules(); err != nil {
+		return nil, err
+	}
+
+	if fileConfig.Logs != nil {
+		if _, err := fileConfig.Logs.Parsers(); err != nil {
+			return nil, err
+		}
+	}
+
 	return &fileConfig, nil
 }
 
@@ -157,7 +255,160 @@ func (c *FileConfig) parseWorkers() error {
 		if v == nil {
 			return errors.Errorf("The \"%s\" worker entry in \".symfony.local.yaml\" cannot be empty.", k)
 		}
+		for _, dep := range v.DependsOn {
+			if _, ok := c.Workers[dep]; !ok {
+				return errors.Errorf("The \"%s\" worker in \".symfony.local.yaml\" depends on the undefined \"%s\" worker.", k, dep)
+			}
+		}
+		if v.Replicas < 0 {
+			return errors.Errorf("The \"%s\" worker replicas in \".symfony.local.yaml\" cannot be negative.", k)
+		}
+		if err := v.Restart.Validate(); err != nil {
+			return errors.Wrapf(err, "The \"%s\" worker in \".symfony.local.yaml\" is invalid", k)
+		}
+		if v.StopSignal != "" {
+			if _, err := process.ParseSignal(v.StopSignal); err != nil {
+				return errors.Wrapf(err, "The \"%s\" worker in \".symfony.local.yaml\" is invalid", k)
+			}
+		}
+		if v.WatchOptions != nil {
+			if err := v.WatchOptions.Validate(); err != nil {
+				return errors.Wrapf(err, "The \"%s\" worker watch options in \".symfony.local.yaml\" are invalid", k)
+			}
+		}
+		if v.Readiness != nil {
+			if err := v.Readiness.Validate(); err != nil {
+				return errors.Wrapf(err, "The \"%s\" worker readiness probe in \".symfony.local.yaml\" is invalid", k)
+			}
+		}
+	}
+
+	if _, err := c.WorkersStartOrder(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReplicasCount returns the number of instances of the worker to run
+func (w *Worker) ReplicasCount() int {
+	if w.Replicas == 0 {
+		return 1
+	}
+	return w.Replicas
+}
+
+func (c *FileConfig) parseSchedules() error {
+	for k, v := range c.Schedules {
+		if v == nil || len(v.Cmd) == 0 {
+			return errors.Errorf("The \"%s\" schedule entry in \".symfony.local.yaml\" must define a command.", k)
+		}
+		if _, err := cron.Parse(v.Spec); err != nil {
+			return errors.Wrapf(err, "The \"%s\" schedule entry in \".symfony.local.yaml\" is invalid", k)
+		}
 	}
 
 	return nil
 }
+
+// WorkDir returns the absolute working directory of the worker
+func (w *Worker) WorkDir(projectDir string) string {
+	if w.Dir == "" {
+		return projectDir
+	}
+	if filepath.IsAbs(w.Dir) {
+		return w.Dir
+	}
+	return filepath.Join(projectDir, w.Dir)
+}
+
+// WorkersStartOrder returns the worker names sorted so that each worker comes
+// after the workers it depends on
+func (c *FileConfig) WorkersStartOrder() ([]string, error) {
+	names := make([]string, 0, len(c.Workers))
+	for name := range c.Workers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	order := make([]string, 0, len(names))
+	// 0: not visited, 1: being visited, 2: done
+	state := make(map[string]int, len(names))
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case 1:
+			return errors.Errorf("Workers in \".symfony.local.yaml\" have a circular dependency: %s", strings.Join(append(path, name), " -> "))
+		case 2:
+			return nil
+		}
+		state[name] = 1
+		deps := append([]string{}, c.Workers[name].DependsOn...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = 2
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// WorkerDependents returns the names of the workers depending, directly or
+// not, on one of the given workers
+func (c *FileConfig) WorkerDependents(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		seen[name] = true
+	}
+	var dependents []string
+	for found := true; found; {
+		found = false
+		for name, worker := range c.Workers {
+			if seen[name] {
+				continue
+			}
+			for _, dep := range worker.DependsOn {
+				if seen[dep] {
+					seen[name] = true
+					dependents = append(dependents, name)
+					found = true
+					break
+				}
+			}
+		}
+	}
+	sort.Strings(dependents)
+	return dependents
+}
+
+// WorkersDiff returns the names of the workers added, removed, and changed
+// in newConfig compared to c
+func (c *FileConfig) WorkersDiff(newConfig *FileConfig) (added, removed, changed []string) {
+	for name, worker := range newConfig.Workers {
+		old, ok := c.Workers[name]
+		if !ok {
+			added = append(added, name)
+		} else if !reflect.DeepEqual(old, worker) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range c.Workers {
+		if _, ok := newConfig.Workers[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return
+}
@@ -21,8 +21,11 @@ package project
 
 import (
 	"testing"
+	"time"
 
+	"github.com/symfony-cli/symfony-cli/local"
 	. "gopkg.in/check.v1"
+	"gopkg.in/yaml.v2"
 )
 
 func Test(t *testing.T) { TestingT(t) }
@@ -34,3 +37,119 @@ var _ = Suite(&ProjectSuite{})
 func (s *ProjectSuite) TestGuessDocumentRoot(c *C) {
 	c.Assert(guessDocumentRoot("testdata"), Equals, "testdata/foobar")
 }
+
+func (s *ProjectSuite) TestWorkersStartOrder(c *C) {
+	config := &FileConfig{Workers: map[string]*Worker{
+		"messenger":  {Cmd: []string{"symfony", "console", "messenger:consume"}, DependsOn: []string{"docker", "migrations"}},
+		"migrations": {Cmd: []string{"symfony", "console", "doctrine:migrations:migrate"}, DependsOn: []string{"docker"}},
+		"docker":     {Cmd: []string{"docker", "compose", "up"}},
+		"encore":     {Cmd: []string{"yarn", "encore", "dev", "--watch"}},
+	}}
+	c.Assert(config.parseWorkers(), IsNil)
+	order, err := config.WorkersStartOrder()
+	c.Assert(err, IsNil)
+	c.Assert(order, DeepEquals, []string{"docker", "encore", "migrations", "messenger"})
+
+	c.Assert(config.WorkerDependents([]string{"docker"}), DeepEquals, []string{"messenger", "migrations"})
+	c.Assert(config.WorkerDependents([]string{"migrations"}), DeepEquals, []string{"messenger"})
+	c.Assert(config.WorkerDependents([]string{"encore"}), IsNil)
+
+	config = &FileConfig{Workers: map[string]*Worker{
+		"a": {Cmd: []string{"a"}, DependsOn: []string{"b"}},
+		"b": {Cmd: []string{"b"}, DependsOn: []string{"a"}},
+	}}
+	c.Assert(config.parseWorkers(), ErrorMatches, ".*circular dependency: a -> b -> a")
+
+	config = &FileConfig{Workers: map[string]*Worker{
+		"a": {Cmd: []string{"a"}, DependsOn: []string{"c"}},
+	}}
+	c.Assert(config.parseWorkers(), ErrorMatches, `.*depends on the undefined "c" worker.*`)
+}
+
+func (s *ProjectSuite) TestWorkerRestartPolicy(c *C) {
+	var config FileConfig
+	c.Assert(yaml.Unmarshal([]byte(`
+workers:
+    messenger:
+        cmd: [symfony, console, messenger:consume, async]
+        dir: app
+        env:
+            APP_DEBUG: 0
+        restart: on-failure
+        max_retries: 3
+        restart_delay: 2s
+        stop_signal: SIGINT
+        stop_timeout: 30s
+`), &config), IsNil)
+	c.Assert(config.parseWorkers(), IsNil)
+	worker := config.Workers["messenger"]
+	c.Assert(worker.Restart, Equals, local.RestartOnFailure)
+	c.Assert(worker.MaxRetries, Equals, 3)
+	c.Assert(worker.RestartDelay, Equals, 2*time.Second)
+	c.Assert(worker.Env, DeepEquals, map[string]string{"APP_DEBUG": "0"})
+	c.Assert(worker.WorkDir("/project"), Equals, "/project/app")
+	c.Assert(worker.StopSignal, Equals, "SIGINT")
+	c.Assert(worker.StopTimeout, Equals, 30*time.Second)
+
+	config.Workers["messenger"].Restart = "sometimes"
+	c.Assert(config.parseWorkers(), ErrorMatches, `.*unknown restart policy "sometimes".*`)
+
+	config.Workers["messenger"].Restart = local.RestartAlways
+	config.Workers["messenger"].StopSignal = "SIGFOO"
+	c.Assert(config.parseWorkers(), ErrorMatches, `.*unsupported signal "SIGFOO".*`)
+}
+
+func (s *ProjectSuite) TestSchedules(c *C) {
+	var config FileConfig
+	c.Assert(yaml.Unmarshal([]byte(`
+schedules:
+    cleanup:
+        spec: "*/5 * * * *"
+        cmd: [symfony, console, app:cleanup]
+`), &config), IsNil)
+	c.Assert(config.parseSchedules(), IsNil)
+	c.Assert(config.Schedules["cleanup"].Cmd, DeepEquals, []string{"symfony", "console", "app:cleanup"})
+
+	config.Schedules["cleanup"].Spec = "*/5 * *"
+	c.Assert(config.parseSchedules(), ErrorMatches, `The "cleanup" schedule entry .* is invalid.*`)
+
+	config.Schedules["cleanup"].Spec = "@daily"
+	config.Schedules["cleanup"].Cmd = nil
+	c.Assert(config.parseSchedules(), ErrorMatches, `.*must define a command.*`)
+}
+
+func (s *ProjectSuite) TestLogFormats(c *C) {
+	var config FileConfig
+	c.Assert(yaml.Unmarshal([]byte(`
+logs:
+    files: [var/log/*.log]
+    formats:
+        custom:
+            regex: '^(?P<level>\w+): (?P<message>.*)$'
+`), &config), IsNil)
+	parsers, err := config.Logs.Parsers()
+	c.Assert(err, IsNil)
+	c.Assert(parsers, HasLen, 1)
+	c.Assert(parsers[0].Name(), Equals, "custom")
+
+	config.Logs.Formats["custom"].Regex = `^(?P<level>\w+)`
+	_, err = config.Logs.Parsers()
+	c.Assert(err, ErrorMatches, `.*must have a "message" named group.*`)
+}
+
+func (s *ProjectSuite) TestWorkersDiff(c *C) {
+	old := &FileConfig{Workers: map[string]*Worker{
+		"encore":    {Cmd: []string{"yarn", "encore", "dev", "--watch"}},
+		"messenger": {Cmd: []string{"symfony", "console", "messenger:consume"}},
+		"removed":   {Cmd: []string{"sleep", "10"}},
+	}}
+	new := &FileConfig{Workers: map[string]*Worker{
+		"encore":    {Cmd: []string{"yarn", "encore", "dev", "--watch"}},
+		"messenger": {Cmd: []string{"symfony", "console", "messenger:consume"}, Replicas: 2},
+		"added":     {Cmd: []string{"sleep", "20"}},
+	}}
+	added, removed, changed := old.WorkersDiff(new)
+	c.Assert(added, DeepEquals, []string{"added"})
+	c.Assert(removed, DeepEquals, []string{"removed"})
+	c.Assert(changed, DeepEquals, []string{"messenger"})
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package projects
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Autostart is the registry of the projects started by "local:boot"
+type Autostart struct {
+	Projects map[string]*AutostartProject `json:"projects"`
+
+	path string
+}
+
+// AutostartProject is a project registered to be started on boot
+type AutostartProject struct {
+	Dir string `json:"dir"`
+}
+
+func LoadAutostart(homeDir string) (*Autostart, error) {
+	a := &Autostart{
+		Projects: make(map[string]*AutostartProject),
+		path:     filepath.Join(homeDir, "autostart.json"),
+	}
+	data, err := ioutil.ReadFile(a.path)
+	if os.IsNotExist(err) {
+		return a, nil
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "unable to read %s", a.path)
+	}
+	if err := json.Unmarshal(data, a); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse %s", a.path)
+	}
+	if a.Projects == nil {
+		a.Projects = make(map[string]*AutostartProject)
+	}
+	return a, nil
+}
+
+// Enable registers the project directory
+func (a *Autostart) Enable(dir string) {
+	a.Projects[dir] = &AutostartProject{Dir: dir}
+}
+
+// Disable unregisters the project directory, it returns false if it was not registered
+func (a *Autostart) Disable(dir string) bool {
+	if _, ok := a.Projects[dir]; !ok {
+		return false
+	}
+	delete(a.Projects, dir)
+	return true
+}
+
+// Dirs returns the registered project directories
+func (a *Autostart) Dirs() []string {
+	dirs := make([]string, 0, len(a.Projects))
+	for dir := range a.Projects {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+func (a *Autostart) Save() error {
+	data, err := json.MarshalIndent(a, "", "    ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(a.path), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.Wrapf(ioutil.WriteFile(a.path, data, 0644), "unable to write %s", a.path)
+}
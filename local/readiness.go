@@ -0,0 +1,190 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package local
+
+import (
+	"bufio"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/symfony-cli/symfony-cli/local/pid"
+)
+
+const (
+	defaultReadinessTimeout  = 60 * time.Second
+	defaultReadinessInterval = 500 * time.Millisecond
+)
+
+// ReadinessProbe describes how to check that a command is ready to be used
+// by the commands depending on it. All configured checks must pass.
+type ReadinessProbe struct {
+	// TCP is an address (host:port) that must accept connections
+	TCP string `yaml:"tcp"`
+	// HTTP is a URL that must answer with a non-error status code
+	HTTP string `yaml:"http"`
+	// Cmd is a command that must exit with a zero status code
+	Cmd []string `yaml:"cmd"`
+	// Log is a regular expression that must match a line of the command logs
+	Log string `yaml:"log"`
+
+	Timeout  time.Duration `yaml:"timeout"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// Validate checks that the probe is usable
+func (p *ReadinessProbe) Validate() error {
+	if p.TCP == "" && p.HTTP == "" && len(p.Cmd) == 0 && p.Log == "" {
+		return errors.New("at least one of \"tcp\", \"http\", \"cmd\" or \"log\" must be defined")
+	}
+	if p.Log != "" {
+		if _, err := regexp.Compile(p.Log); err != nil {
+			return errors.Wrapf(err, "invalid \"log\" regular expression")
+		}
+	}
+	return nil
+}
+
+// Wait blocks until the probe succeeds for the command described by pidFile
+// or until the timeout is reached. The checks only start once the pid file
+// references the new process, so that the logs of a previous run are never
+// matched. stopped, if not nil, is closed when the command runner gives up.
+func (p *ReadinessProbe) Wait(pidFile *pid.PidFile, env []string, stopped <-chan bool) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = defaultReadinessTimeout
+	}
+	interval := p.Interval
+	if interval <= 0 {
+		interval = defaultReadinessInterval
+	}
+
+	var logRegexp *regexp.Regexp
+	if p.Log != "" {
+		logRegexp = regexp.MustCompile(p.Log)
+	}
+
+	deadline := time.Now().Add(timeout)
+	startedPid := 0
+	for {
+		err := p.waitForProcess(pidFile, &startedPid)
+		if err == nil {
+			err = p.check(pidFile, env, logRegexp)
+			if err == nil {
+				return nil
+			}
+		} else if errors.Cause(err) == errProcessExited {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return errors.Wrapf(err, "not ready after %s", timeout)
+		}
+		select {
+		case <-stopped:
+			return errors.WithStack(errProcessExited)
+		case <-time.After(interval):
+		}
+	}
+}
+
+var errProcessExited = errors.New("the command exited before being ready")
+
+// waitForProcess returns nil when the process referenced by the pid file is
+// running; startedPid keeps track of the first running process seen
+func (p *ReadinessProbe) waitForProcess(pidFile *pid.PidFile, startedPid *int) error {
+	current, err := pid.Load(pidFile.PidFile())
+	running := err == nil && current.IsRunning()
+	if *startedPid != 0 {
+		if !running || current.Pid != *startedPid {
+			return errors.WithStack(errProcessExited)
+		}
+		return nil
+	}
+	if !running {
+		return errors.New("the command is not running yet")
+	}
+	*startedPid = current.Pid
+	return nil
+}
+
+func (p *ReadinessProbe) check(pidFile *pid.PidFile, env []string, logRegexp *regexp.Regexp) error {
+	if p.TCP != "" {
+		conn, err := net.DialTimeout("tcp", p.TCP, time.Second)
+		if err != nil {
+			return errors.Wrapf(err, "TCP check on %s failed", p.TCP)
+		}
+		conn.Close()
+	}
+
+	if p.HTTP != "" {
+		client := &http.Client{
+			Timeout: 5 * time.Second,
+			// the local web server and Docker services use self-signed certificates
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+		}
+		resp, err := client.Get(p.HTTP)
+		if err != nil {
+			return errors.Wrapf(err, "HTTP check on %s failed", p.HTTP)
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return errors.Errorf("HTTP check on %s failed: got status code %d", p.HTTP, resp.StatusCode)
+		}
+	}
+
+	if len(p.Cmd) > 0 {
+		cmd := exec.Command(p.Cmd[0], p.Cmd[1:]...)
+		cmd.Dir = pidFile.Dir
+		cmd.Env = append(os.Environ(), env...)
+		if err := cmd.Run(); err != nil {
+			return errors.Wrapf(err, "command check \"%s\" failed", cmd)
+		}
+	}
+
+	if logRegexp != nil {
+		f, err := os.Open(pidFile.LogFile())
+		if err != nil {
+			return errors.Wrap(err, "log check failed")
+		}
+		defer f.Close()
+		found := false
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if logRegexp.Match(scanner.Bytes()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("log check failed: no line matching \"%s\"", p.Log)
+		}
+	}
+
+	return nil
+}
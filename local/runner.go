@@ -21,6 +21,7 @@ package local
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -28,6 +29,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -35,6 +37,8 @@ import (
 	"github.com/symfony-cli/console"
 	"github.com/symfony-cli/symfony-cli/inotify"
 	"github.com/symfony-cli/symfony-cli/local/pid"
+	"github.com/symfony-cli/symfony-cli/local/process"
+	"github.com/symfony-cli/symfony-cli/local/supervisor"
 	"github.com/symfony-cli/symfony-cli/reexec"
 	"github.com/symfony-cli/symfony-cli/util"
 	"github.com/symfony-cli/terminal"
@@ -50,24 +54,79 @@ const (
 
 const RunnerReliefDuration = 2 * time.Second
 
+const (
+	defaultRestartDelay    = 5 * time.Second
+	defaultMaxRestartDelay = time.Minute
+	// a command running for longer than this is considered healthy again
+	// and its restart delay and retries count are reset
+	runnerBackoffResetDuration = time.Minute
+)
+
+type RestartPolicy string
+
+const (
+	RestartAlways    RestartPolicy = "always"     // restart the command whenever it exits
+	RestartOnFailure RestartPolicy = "on-failure" // restart the command only when it exits with an error
+	RestartNever     RestartPolicy = "never"      // never restart the command (except on watched files changes)
+)
+
+// Validate checks that the policy is a known one (an empty policy means the default one)
+func (p RestartPolicy) Validate() error {
+	switch p {
+	case "", RestartAlways, RestartOnFailure, RestartNever:
+		return nil
+	}
+	return errors.Errorf("unknown restart policy \"%s\", must be one of \"%s\", \"%s\", or \"%s\"", p, RestartAlways, RestartOnFailure, RestartNever)
+}
+
 type RunnerWentToBackground struct{}
 
 func (RunnerWentToBackground) Error() string { return "" }
 
 type Runner struct {
-	binary  string
-	mode    runnerMode
-	pidFile *pid.PidFile
-
-	BuildCmdHook        func(*exec.Cmd) error
-	AlwaysRestartOnExit bool
+	binary   string
+	mode     runnerMode
+	pidFile  *pid.PidFile
+	stopChan chan bool
+	stopped  chan bool
+
+	BuildCmdHook func(*exec.Cmd) error
+
+	// RestartPolicy defaults to RestartAlways for looping modes and to
+	// RestartNever otherwise (where only watched files changes restart the command)
+	RestartPolicy RestartPolicy
+	// MaxRetries is the number of consecutive restarts after a failure
+	// before giving up (0 means no limit)
+	MaxRetries int
+	// RestartDelay is the delay before restarting a failed command, doubled
+	// after each consecutive failure up to MaxRestartDelay
+	RestartDelay    time.Duration
+	MaxRestartDelay time.Duration
+
+	// WatchOptions filters and debounces the changes in the watched paths
+	WatchOptions *WatchOptions
+
+	// AppendLogs appends the output of each run to the log file and keeps
+	// it when the command exits (useful for commands run periodically)
+	AppendLogs bool
+
+	// logs is the log file writer shared by all the runs of the command,
+	// restarts append to it to keep the crash history
+	logs io.WriteCloser
+	// childOutput is the write end of the pipe the current run writes its
+	// output to, closed on our side once the command is started
+	childOutput *os.File
+	// copies tracks the copies of the output of the runs to the log file
+	copies sync.WaitGroup
 }
 
 func NewRunner(pidFile *pid.PidFile, mode runnerMode) (*Runner, error) {
 	var err error
 	r := &Runner{
-		mode:    mode,
-		pidFile: pidFile,
+		mode:     mode,
+		pidFile:  pidFile,
+		stopChan: make(chan bool, 1),
+		stopped:  make(chan bool),
 	}
 	r.binary, err = exec.LookPath(pidFile.Binary())
 	if err != nil {
@@ -78,14 +137,40 @@ func NewRunner(pidFile *pid.PidFile, mode runnerMode) (*Runner, error) {
 	return r, nil
 }
 
+// Stopped returns a channel closed when Run returns
+func (r *Runner) Stopped() <-chan bool {
+	return r.stopped
+}
+
+// Stop asks the running command to stop and waits for Run to return
+func (r *Runner) Stop() {
+	select {
+	case r.stopChan <- true:
+	default:
+	}
+	<-r.stopped
+}
+
 func (r *Runner) Run() error {
+	defer close(r.stopped)
+	defer func() {
+		// in detached mode, our own output goes to the log file as well
+		if r.logs != nil && r.mode != RunnerModeLoopDetached {
+			// children of the command might still be writing to it
+			go func() {
+				r.copies.Wait()
+				r.logs.Close()
+			}()
+		}
+	}()
+
 	if r.mode == RunnerModeLoopDetached {
 		if !reexec.IsChild() {
 			varDir := filepath.Join(util.GetHomeDir(), "var")
 			if err := os.MkdirAll(varDir, 0755); err != nil {
 				return errors.Wrap(err, "Could not create status file")
 			}
-			err := reexec.Background(varDir)
+			err := supervisor.Background(varDir)
 			if err == nil {
 				return RunnerWentToBackground{}
 			}
@@ -116,20 +201,31 @@ func (r *Runner) Run() error {
 		c := make(chan inotify.EventInfo, 10)
 		defer inotify.Stop(c)
 
+		filter, err := newWatchFilter(r.pidFile.Dir, r.WatchOptions)
+		if err != nil {
+			return errors.Wrap(err, "invalid watch options")
+		}
+		debounce := r.WatchOptions.debounce()
+
 		go func() {
+			// wait for changes to settle down before restarting to avoid
+			// restarting several times when many files change at once
+			timer := time.NewTimer(time.Hour)
+			timer.Stop()
 			for {
-				event := <-c
-
-				// ignore vim temporary files events
-				if strings.HasSuffix(filepath.Ext(event.Path()), "~") {
-					continue
-				}
-
-				terminal.Logger.Debug().Msg("Got event: " + event.Event().String())
-
 				select {
-				case restartChan <- true:
-				default:
+				case event := <-c:
+					if !filter.Match(event.Path()) {
+						continue
+					}
+
+					terminal.Logger.Debug().Msg("Got event: " + event.Event().String())
+					timer.Reset(debounce)
+				case <-timer.C:
+					select {
+					case restartChan <- true:
+					default:
+					}
 				}
 			}
 		}()
@@ -158,17 +254,31 @@ func (r *Runner) Run() error {
 	timer.Stop()
 
 	pid := os.Getpid()
+	retries := 0
+	restarting := false
 
 	for {
+		if restarting {
+			r.pidFile.Restarts++
+			// in attached mode, the pid file is written again with the new PID below
+			if r.mode == RunnerModeLoopDetached {
+				if err := r.pidFile.Update(); err != nil {
+					terminal.Logger.Warn().Msgf("Unable to update the pid file: %s", err)
+				}
+			}
+		}
+		restarting = true
+
 		cmd, err := r.buildCmd()
 		if err != nil {
 			return errors.Wrap(err, "unable to build cmd")
 		}
 
-		if err := cmd.Start(); err != nil {
+		if err := r.startCmd(cmd); err != nil {
 			return errors.Wrapf(err, `command "%s" failed to start`, r.pidFile)
 		}
 
+		startedAt := time.Now()
 		go func() { cmdExitChan <- cmd.Wait() }()
 
 		if firstBoot {
@@ -198,11 +308,13 @@ func (r *Runner) Run() error {
 		}
 		if firstBoot || r.mode == RunnerModeLoopAttached {
 			if err := r.pidFile.Write(pid, 0, ""); err != nil {
+				// another process might have started the same command concurrently
+				r.stopCmd(cmd, os.Kill, cmdExitChan)
 				return errors.Wrap(err, "unable to write pid file")
 			}
 		}
 		if firstBoot && r.mode == RunnerModeLoopDetached {
-			terminal.RemapOutput(cmd.Stdout, cmd.Stderr).SetDecorated(true)
+			terminal.RemapOutput(r.logs, r.logs).SetDecorated(true)
 			reexec.NotifyForeground(reexec.UP)
 		}
 
@@ -211,41 +323,210 @@ func (r *Runner) Run() error {
 		select {
 		case sig := <-sigChan:
 			terminal.Logger.Info().Msgf("Signal \"%s\" received, forwarding to command and exiting\n", sig)
+			if r.mode != RunnerModeOnce {
+				r.stopCmd(cmd, sig, cmdExitChan)
+				return nil
+			}
 			err := cmd.Process.Signal(sig)
 			if err != nil && runtime.GOOS == "windows" && strings.Contains(err.Error(), "not supported by windows") {
 				return exec.Command("CMD", "/C", "TASKKILL", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
 			}
 			return err
+		case <-r.stopChan:
+			terminal.Logger.Info().Msgf(`Stopping command "%s"`, r.pidFile)
+			sig, _ := r.pidFile.StopSettings()
+			r.stopCmd(cmd, sig, cmdExitChan)
+			return r.pidFile.Remove()
 		case <-restartChan:
-			// We use SIGTERM here because it's nicer and thus when we use our
-			// wrappers, signal will be nicely forwarded
-			cmd.Process.Signal(syscall.SIGTERM)
-			// we need to drain cmdExit channel to unblock cmd channel receiver
-			<-cmdExitChan
+			// The stop signal is SIGTERM by default because it's nicer and
+			// thus when we use our wrappers, signal will be nicely forwarded
+			sig, _ := r.pidFile.StopSettings()
+			r.stopCmd(cmd, sig, cmdExitChan)
 		case err := <-cmdExitChan:
+			exitCode := exitCode(err)
+			r.pidFile.LastExitCode = &exitCode
 			err = errors.Wrapf(err, `command "%s" failed`, r.pidFile)
 
-			if looping {
-				// Command exited, let's wait for a change or 5 seconds to restart the command or a signal to exit
+			if !looping {
+				if err == nil {
+					return r.pidFile.Remove()
+				}
+
+				return err
+			}
+
+			if time.Since(startedAt) > runnerBackoffResetDuration {
+				retries = 0
+			}
+
+			if !r.shouldRestart(err) {
+				if len(r.pidFile.Watched) == 0 {
+					if err == nil {
+						return r.pidFile.Remove()
+					}
+
+					return err
+				}
+
+				// Command exited, let's wait for a change to restart the command or a signal to exit
 				if err != nil {
-					terminal.Logger.Error().Msgf("%s, waiting 5 seconds before restarting it", err)
-					timer.Reset(5 * time.Second)
-				} else if r.AlwaysRestartOnExit {
-					terminal.Logger.Error().Msgf(`command "%s" exited, restarting it immediately`, r.pidFile)
+					terminal.Logger.Error().Msgf("%s, waiting for a change to restart it", err)
+				}
+				select {
+				case <-sigChan:
+					return err
+				case <-r.stopChan:
+					return r.pidFile.Remove()
+				case <-restartChan:
 				}
+				break
+			}
 
+			if err == nil {
+				terminal.Logger.Error().Msgf(`command "%s" exited, restarting it immediately`, r.pidFile)
 				continue
 			}
 
-			if err == nil {
+			retries++
+			if r.MaxRetries > 0 && retries > r.MaxRetries {
+				return errors.Wrapf(err, "giving up after %d retries", r.MaxRetries)
+			}
+
+			// Command failed, let's wait for a change or the restart delay to restart the command or a signal to exit
+			delay := r.restartDelay(retries)
+			terminal.Logger.Error().Msgf("%s, waiting %s before restarting it", err, delay)
+			timer.Reset(delay)
+			select {
+			case <-sigChan:
+				timer.Stop()
+				return err
+			case <-r.stopChan:
+				timer.Stop()
 				return r.pidFile.Remove()
+			case <-restartChan:
+				timer.Stop()
+			case <-timer.C:
 			}
+		}
+
+		terminal.Logger.Info().Msgf(`Restarting command "%s"`, r.pidFile)
+	}
+}
+
+// stopCmd sends sig to the command and its children and waits for the
+// command to exit, it is killed if still running after the stop timeout
+func (r *Runner) stopCmd(cmd *exec.Cmd, sig os.Signal, cmdExitChan chan error) {
+	_, timeout := r.pidFile.StopSettings()
+	if err := process.SignalGroup(cmd.Process.Pid, sig); err != nil {
+		terminal.Logger.Debug().Msgf(`Unable to send signal "%s" to command "%s": %s`, sig, r.pidFile, err)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-cmdExitChan:
+	case <-timer.C:
+		terminal.Logger.Warn().Msgf(`Command "%s" did not stop after %s, killing it`, r.pidFile, timeout)
+		process.SignalGroup(cmd.Process.Pid, os.Kill)
+		<-cmdExitChan
+	}
+
+	// make sure no child of the command is left behind
+	process.SignalGroup(cmd.Process.Pid, os.Kill)
+}
+
+// exitCode returns the exit code of a command from the error returned by Wait
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+func (r *Runner) shouldRestart(err error) bool {
+	policy := r.RestartPolicy
+	if policy == "" {
+		policy = RestartAlways
+		if r.mode == RunnerModeOnce {
+			policy = RestartNever
+		}
+	}
 
+	switch policy {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	}
+	return false
+}
+
+// restartDelay returns the delay to wait before the nth consecutive restart
+func (r *Runner) restartDelay(retries int) time.Duration {
+	delay := r.RestartDelay
+	if delay <= 0 {
+		delay = defaultRestartDelay
+	}
+	maxDelay := r.MaxRestartDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultMaxRestartDelay
+	}
+	for i := 1; i < retries && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+func (r *Runner) logWriter() (io.WriteCloser, error) {
+	if r.AppendLogs {
+		return r.pidFile.LogAppender()
+	}
+	return r.pidFile.LogWriter()
+}
+
+// pipeOutput makes the command write its output to a pipe copied to the log
+// file. Giving the command a real file descriptor keeps cmd.Wait() from
+// waiting for the children that inherited the output of the command.
+func (r *Runner) pipeOutput(cmd *exec.Cmd) error {
+	if r.logs == nil {
+		logs, err := r.logWriter()
+		if err != nil {
 			return err
 		}
+		r.logs = logs
+	}
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+	r.childOutput = pw
+	logs := r.logs
+	r.copies.Add(1)
+	go func() {
+		defer r.copies.Done()
+		io.Copy(logs, pr)
+		pr.Close()
+	}()
+	return nil
+}
 
-		terminal.Logger.Info().Msgf(`Restarting command "%s"`, r.pidFile)
+// startCmd starts the command; the copy of its output ends once the command
+// and all its children have exited
+func (r *Runner) startCmd(cmd *exec.Cmd) error {
+	err := cmd.Start()
+	if r.childOutput != nil {
+		r.childOutput.Close()
+		r.childOutput = nil
 	}
+	return err
 }
 
 func (r *Runner) buildCmd() (*exec.Cmd, error) {
@@ -257,11 +538,6 @@ func (r *Runner) buildCmd() (*exec.Cmd, error) {
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		cmd.Stdin = os.Stdin
-	} else if logWriter, err := r.pidFile.LogWriter(); err != nil {
-		return nil, errors.WithStack(err)
-	} else {
-		cmd.Stdout = logWriter
-		cmd.Stderr = logWriter
 	}
 
 	if r.BuildCmdHook != nil {
@@ -270,5 +546,17 @@ func (r *Runner) buildCmd() (*exec.Cmd, error) {
 		}
 	}
 
+	if r.mode != RunnerModeOnce {
+		if err := r.pipeOutput(cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.mode == RunnerModeLoopAttached {
+		// we share our process group with the web server, so we give the
+		// command its own to be able to stop it with all its children
+		process.SetProcessGroup(cmd)
+	}
+
 	return cmd, nil
 }
@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package local
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/symfony-cli/symfony-cli/local/pid"
+	. "gopkg.in/check.v1"
+)
+
+type RunnerSuite struct{}
+
+var _ = Suite(&RunnerSuite{})
+
+func (s *RunnerSuite) TestRunDoesNotWaitForChildrenOfTheCommand(c *C) {
+	home, err := ioutil.TempDir("", "symfony-runner")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(home)
+	oldHome := os.Getenv("HOME")
+	homedir.Reset()
+	os.Setenv("HOME", home)
+	defer func() {
+		os.Setenv("HOME", oldHome)
+		homedir.Reset()
+	}()
+
+	// the background sleep inherits the output of the command
+	pidFile := pid.New(home, []string{"sh", "-c", "sleep 10 & echo started"})
+	r, err := NewRunner(pidFile, RunnerModeLoopAttached)
+	c.Assert(err, IsNil)
+	r.RestartPolicy = RestartNever
+
+	done := make(chan error, 1)
+	go func() { done <- r.Run() }()
+	select {
+	case err := <-done:
+		c.Assert(err, IsNil)
+	case <-time.After(RunnerReliefDuration + 5*time.Second):
+		c.Fatal("the runner waited for the children of the command")
+	}
+
+	// the output is copied to the log file asynchronously
+	var contents []byte
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		if contents, err = ioutil.ReadFile(pidFile.LogFile()); err == nil && len(contents) > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(string(contents), Equals, "started\n")
+}
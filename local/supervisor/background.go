@@ -0,0 +1,149 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package supervisor
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/symfony-cli/console"
+	"github.com/symfony-cli/symfony-cli/local/process"
+	"github.com/symfony-cli/symfony-cli/reexec"
+	"github.com/symfony-cli/symfony-cli/util"
+	"github.com/symfony-cli/terminal"
+)
+
+// Background runs the current command in the background; the supervisor
+// runs it when started, otherwise the command is detached on its own
+func Background(varDir string) error {
+	if util.IsGoRun() {
+		return reexec.Background(varDir)
+	}
+	conn, err := dial()
+	if err != nil {
+		return reexec.Background(varDir)
+	}
+	defer conn.Close()
+
+	terminal.Logger.Debug().Msg("Let's go to the background via the supervisor!")
+
+	argv0, err := console.CurrentBinaryPath()
+	if err != nil {
+		return err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	statusFile, err := ioutil.TempFile(varDir, "status-")
+	if err != nil {
+		return errors.Wrap(err, "Could not create status file")
+	}
+	statusFile.Close()
+	// the status file is only removed by the command once up and running or by
+	// the supervisor once the command has exited, as its presence tells the
+	// supervisor that the command did not start
+	// the output of the command is sent to a file as we cannot share our
+	// file descriptors with the supervisor
+	output, err := ioutil.TempFile(varDir, "output-")
+	if err != nil {
+		return errors.Wrap(err, "Could not create output file")
+	}
+	defer os.Remove(output.Name())
+	defer output.Close()
+
+	var env []string
+	for _, v := range os.Environ() {
+		if !strings.HasPrefix(v, "REEXEC_") {
+			env = append(env, v)
+		}
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := send(conn, reader, &request{
+		Action:     actionSpawn,
+		Dir:        wd,
+		Args:       append([]string{argv0}, os.Args[1:]...),
+		Env:        env,
+		StatusFile: statusFile.Name(),
+		OutputFile: output.Name(),
+	})
+	if err != nil {
+		os.Remove(statusFile.Name())
+		return err
+	}
+
+	statusCh := make(chan int, 1)
+	go func() {
+		// the supervisor only sends something else if the command exits
+		// before being up and running
+		resp, err := readResponse(reader)
+		if err == nil && resp.Exited {
+			statusCh <- resp.Status
+		}
+	}()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+	defer signal.Stop(sigChan)
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.NewTimer(5 * time.Second)
+	defer timeout.Stop()
+	for {
+		select {
+		case status := <-statusCh:
+			io.Copy(os.Stdout, output)
+			return console.Exit("", status)
+		case <-sigChan:
+			cancel(resp.Pid)
+			return errors.New("interrupted while waiting for the command to start")
+		case <-timeout.C:
+			cancel(resp.Pid)
+			return errors.New("reexec timed out")
+		case <-ticker.C:
+			fi, err := os.Stat(statusFile.Name())
+			io.Copy(os.Stdout, output)
+			if os.IsNotExist(err) {
+				return nil
+			}
+			if err == nil && fi.Size() > 0 {
+				// the command has notified us that it is starting
+				timeout.Stop()
+			}
+		}
+	}
+}
+
+// cancel kills a command that did not start in time; the supervisor must not
+// restart it
+func cancel(pid int) {
+	if err := Release(pid); err != nil {
+		terminal.Logger.Warn().Msgf("Unable to release process %d: %s", pid, err)
+	}
+	process.SignalGroup(pid, os.Kill)
+}
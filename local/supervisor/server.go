@@ -0,0 +1,341 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package supervisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/symfony-cli/symfony-cli/local/process"
+	"github.com/symfony-cli/terminal"
+)
+
+const (
+	// a process is given up after this number of consecutive crashes
+	maxRestarts = 5
+	// a process running for longer than this is considered healthy
+	restartResetDuration = time.Minute
+	maxRestartDelay      = time.Minute
+	stopTimeout          = 10 * time.Second
+)
+
+// Supervisor runs processes on behalf of the CLI commands and restarts them
+// when they crash
+type Supervisor struct {
+	// output of the processes once they are up and running
+	output io.Writer
+
+	listener net.Listener
+	shutdown chan bool
+	once     sync.Once
+
+	mu        sync.Mutex
+	processes map[*managedProcess]bool
+}
+
+type managedProcess struct {
+	Process
+
+	env      []string
+	cmd      *exec.Cmd
+	stopping bool
+}
+
+func New(output io.Writer) *Supervisor {
+	return &Supervisor{
+		output:    output,
+		shutdown:  make(chan bool),
+		processes: make(map[*managedProcess]bool),
+	}
+}
+
+// Listen opens the control socket
+func (s *Supervisor) Listen() error {
+	if IsRunning() {
+		return errors.New("The supervisor is already running")
+	}
+	// remove the socket left behind by a supervisor that did not exit cleanly
+	path := socketPath()
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return errors.Wrap(err, "unable to open the supervisor socket")
+	}
+	s.listener = l
+	return nil
+}
+
+// Serve handles the requests until the supervisor is shut down or stopCh
+// receives a value, all the processes are then stopped
+func (s *Supervisor) Serve(stopCh <-chan bool) error {
+	defer os.Remove(socketPath())
+	defer s.listener.Close()
+
+	go func() {
+		for {
+			conn, err := s.listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+
+	select {
+	case <-stopCh:
+	case <-s.shutdown:
+	}
+	s.stopAll()
+	return nil
+}
+
+func (s *Supervisor) handle(conn net.Conn) {
+	defer conn.Close()
+
+	var req request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return
+	}
+	enc := json.NewEncoder(conn)
+	switch req.Action {
+	case actionSpawn:
+		s.spawn(&req, enc)
+	case actionList:
+		enc.Encode(&response{Processes: s.list()})
+	case actionRelease:
+		s.release(req.Pid)
+		enc.Encode(&response{})
+	case actionShutdown:
+		enc.Encode(&response{})
+		s.once.Do(func() { close(s.shutdown) })
+	default:
+		enc.Encode(&response{Error: fmt.Sprintf("unknown action \"%s\"", req.Action)})
+	}
+}
+
+func (s *Supervisor) spawn(req *request, enc *json.Encoder) {
+	output, err := os.OpenFile(req.OutputFile, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		enc.Encode(&response{Error: err.Error()})
+		return
+	}
+	defer output.Close()
+
+	p := &managedProcess{
+		Process: Process{Dir: req.Dir, Args: req.Args},
+		env:     req.Env,
+	}
+	// the command uses the same protocol as when it detaches on its own to
+	// notify that it is up and running
+	env := append(s.childEnv(p), "REEXEC_STATUS_FILE="+req.StatusFile)
+	if err := s.start(p, env, output); err != nil {
+		enc.Encode(&response{Error: err.Error()})
+		return
+	}
+	enc.Encode(&response{Pid: p.Pid})
+
+	err = p.cmd.Wait()
+	if _, statErr := os.Stat(req.StatusFile); statErr == nil {
+		// the command exited before being up and running, or the caller
+		// gave up waiting for it
+		os.Remove(req.StatusFile)
+		s.remove(p)
+		enc.Encode(&response{Exited: true, Status: exitCode(err)})
+		return
+	}
+
+	go s.supervise(p, err)
+}
+
+// supervise restarts the process when it crashes
+func (s *Supervisor) supervise(p *managedProcess, err error) {
+	restarts := 0
+	delay := time.Second
+	for {
+		s.mu.Lock()
+		stopping := p.stopping
+		s.mu.Unlock()
+
+		status := exitCode(err)
+		if stopping || !crashed(err) {
+			terminal.Logger.Info().Msgf("Process %d (%s) exited with status %d", p.Pid, p.Dir, status)
+			s.remove(p)
+			return
+		}
+
+		if time.Since(p.StartedAt) > restartResetDuration {
+			restarts = 0
+			delay = time.Second
+		}
+		restarts++
+		if restarts > maxRestarts {
+			terminal.Logger.Error().Msgf("Process %d (%s) crashed %d times in a row, giving up", p.Pid, p.Dir, maxRestarts)
+			s.remove(p)
+			return
+		}
+
+		terminal.Logger.Warn().Msgf("Process %d (%s) crashed with status %d, restarting it in %s", p.Pid, p.Dir, status, delay)
+		select {
+		case <-s.shutdown:
+			s.remove(p)
+			return
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxRestartDelay {
+			delay = maxRestartDelay
+		}
+
+		s.mu.Lock()
+		p.Restarts++
+		s.mu.Unlock()
+		if err := s.start(p, s.childEnv(p), s.output); err != nil {
+			terminal.Logger.Error().Msgf("Unable to restart process (%s): %s", p.Dir, err)
+			s.remove(p)
+			return
+		}
+		err = p.cmd.Wait()
+	}
+}
+
+func (s *Supervisor) childEnv(p *managedProcess) []string {
+	env := make([]string, len(p.env), len(p.env)+2)
+	copy(env, p.env)
+	// the command must behave as if it has been detached already
+	return append(env, fmt.Sprintf("REEXEC_WATCH_PID=%d", os.Getpid()))
+}
+
+func (s *Supervisor) start(p *managedProcess, env []string, output io.Writer) error {
+	if len(p.Args) == 0 {
+		return errors.New("no command to run")
+	}
+	cmd := exec.Command(p.Args[0], p.Args[1:]...)
+	cmd.Dir = p.Dir
+	cmd.Env = env
+	cmd.Stdout = output
+	cmd.Stderr = output
+	process.SetProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p.cmd = cmd
+	p.Pid = cmd.Process.Pid
+	p.StartedAt = time.Now()
+	s.processes[p] = true
+	terminal.Logger.Info().Msgf("Started process %d (%s)", p.Pid, p.Dir)
+	return nil
+}
+
+func (s *Supervisor) remove(p *managedProcess) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.processes, p)
+}
+
+// release marks the process as stopping so that it is not restarted
+func (s *Supervisor) release(pid int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for p := range s.processes {
+		if p.Pid == pid {
+			p.stopping = true
+		}
+	}
+}
+
+func (s *Supervisor) list() []*Process {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	processes := []*Process{}
+	for p := range s.processes {
+		process := p.Process
+		processes = append(processes, &process)
+	}
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].StartedAt.Before(processes[j].StartedAt)
+	})
+	return processes
+}
+
+// stopAll stops the processes, they are killed if still running after the stop timeout
+func (s *Supervisor) stopAll() {
+	s.mu.Lock()
+	for p := range s.processes {
+		p.stopping = true
+		process.SignalGroup(p.Pid, syscall.SIGTERM)
+	}
+	s.mu.Unlock()
+
+	for deadline := time.Now().Add(stopTimeout); time.Now().Before(deadline); {
+		s.mu.Lock()
+		remaining := len(s.processes)
+		s.mu.Unlock()
+		if remaining == 0 {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for p := range s.processes {
+		process.SignalGroup(p.Pid, os.Kill)
+	}
+}
+
+// crashed returns true if the process exited with an error or has been
+// killed by a fatal signal (not by a stop command)
+func crashed(err error) bool {
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return false
+	}
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		switch status.Signal() {
+		case syscall.SIGSEGV, syscall.SIGBUS, syscall.SIGILL, syscall.SIGFPE, syscall.SIGABRT:
+			return true
+		}
+		return false
+	}
+	return exitErr.ExitCode() > 0
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
@@ -0,0 +1,177 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package supervisor implements an optional per-user daemon owning the
+// processes that the CLI runs in the background (web servers, workers, and
+// the proxy) instead of detaching each of them on its own.
+package supervisor
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/symfony-cli/symfony-cli/local/pid"
+	"github.com/symfony-cli/symfony-cli/util"
+)
+
+const (
+	actionSpawn    = "spawn"
+	actionList     = "list"
+	actionShutdown = "shutdown"
+	actionRelease  = "release"
+)
+
+// Process is a process owned by the supervisor
+type Process struct {
+	Pid       int       `json:"pid"`
+	Dir       string    `json:"dir"`
+	Args      []string  `json:"args"`
+	Restarts  int       `json:"restarts"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+type request struct {
+	Action     string   `json:"action"`
+	Pid        int      `json:"pid,omitempty"`
+	Dir        string   `json:"dir,omitempty"`
+	Args       []string `json:"args,omitempty"`
+	Env        []string `json:"env,omitempty"`
+	StatusFile string   `json:"status_file,omitempty"`
+	OutputFile string   `json:"output_file,omitempty"`
+}
+
+type response struct {
+	Error     string     `json:"error,omitempty"`
+	Pid       int        `json:"pid,omitempty"`
+	Exited    bool       `json:"exited,omitempty"`
+	Status    int        `json:"status,omitempty"`
+	Processes []*Process `json:"processes,omitempty"`
+}
+
+// PidFile returns the pid file of the supervisor
+func PidFile() *pid.PidFile {
+	p := pid.New("__supervisor__", nil)
+	p.CustomName = "Supervisor"
+	return p
+}
+
+func socketPath() string {
+	return filepath.Join(util.GetHomeDir(), "supervisor.sock")
+}
+
+// IsRunning returns true if the supervisor accepts connections
+func IsRunning() bool {
+	conn, err := dial()
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func dial() (net.Conn, error) {
+	conn, err := net.DialTimeout("unix", socketPath(), time.Second)
+	return conn, errors.WithStack(err)
+}
+
+// call sends a request to the supervisor and returns its first response
+func call(req *request) (*response, error) {
+	conn, err := dial()
+	if err != nil {
+		return nil, errors.Wrap(err, "the supervisor is not running")
+	}
+	defer conn.Close()
+
+	return send(conn, bufio.NewReader(conn), req)
+}
+
+// send writes a request to the supervisor and reads its response with r,
+// which must be the only reader of the connection as it buffers what it reads
+func send(conn net.Conn, r *bufio.Reader, req *request) (*response, error) {
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	resp, err := readResponse(r)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+func readResponse(r *bufio.Reader) (*response, error) {
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var resp response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &resp, nil
+}
+
+// List returns the processes owned by the supervisor
+func List() ([]*Process, error) {
+	resp, err := call(&request{Action: actionList})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Processes, nil
+}
+
+// Find returns the supervised process with the given PID, nil if the
+// supervisor is not running or does not own the process
+func Find(pid int) *Process {
+	if pid == 0 || !IsRunning() {
+		return nil
+	}
+	processes, err := List()
+	if err != nil {
+		return nil
+	}
+	for _, p := range processes {
+		if p.Pid == pid {
+			return p
+		}
+	}
+	return nil
+}
+
+// Release tells the supervisor not to restart the process with the given PID
+// when it exits; it must be called before stopping a supervised process
+func Release(pid int) error {
+	if pid == 0 || !IsRunning() {
+		return nil
+	}
+	_, err := call(&request{Action: actionRelease, Pid: pid})
+	return err
+}
+
+// Shutdown stops the supervisor and all its processes
+func Shutdown() error {
+	_, err := call(&request{Action: actionShutdown})
+	return err
+}
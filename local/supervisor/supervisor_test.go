@@ -0,0 +1,79 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package supervisor_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/symfony-cli/symfony-cli/local/supervisor"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type SupervisorSuite struct{}
+
+var _ = Suite(&SupervisorSuite{})
+
+func (s *SupervisorSuite) TestStartAndShutdown(c *C) {
+	home, err := ioutil.TempDir("", "symfony-supervisor")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(home)
+	oldHome := os.Getenv("HOME")
+	homedir.Reset()
+	os.Setenv("HOME", home)
+	defer func() {
+		os.Setenv("HOME", oldHome)
+		homedir.Reset()
+	}()
+	c.Assert(os.MkdirAll(filepath.Join(home, ".symfony5"), 0755), IsNil)
+
+	// like supervisor:start, the output is the supervisor log writer
+	lw, err := supervisor.PidFile().LogWriter()
+	c.Assert(err, IsNil)
+	defer lw.Close()
+
+	sup := supervisor.New(lw)
+	c.Assert(sup.Listen(), IsNil)
+	done := make(chan error, 1)
+	go func() { done <- sup.Serve(make(chan bool)) }()
+
+	c.Assert(supervisor.IsRunning(), Equals, true)
+	processes, err := supervisor.List()
+	c.Assert(err, IsNil)
+	c.Assert(processes, HasLen, 0)
+	// processes not owned by the supervisor are left alone
+	c.Assert(supervisor.Find(os.Getpid()), IsNil)
+	c.Assert(supervisor.Release(os.Getpid()), IsNil)
+
+	c.Assert(supervisor.Shutdown(), IsNil)
+	select {
+	case err := <-done:
+		c.Assert(err, IsNil)
+	case <-time.After(5 * time.Second):
+		c.Fatal("the supervisor did not shut down")
+	}
+	c.Assert(supervisor.IsRunning(), Equals, false)
+}
@@ -0,0 +1,234 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package local
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const defaultWatchDebounce = 500 * time.Millisecond
+
+// WatchOptions tells which changes in the watched paths restart a command
+type WatchOptions struct {
+	// Include restricts the changes to the files matching these glob
+	// patterns (relative to the project directory, "**" matches any number
+	// of directories, patterns without "/" match file names at any depth)
+	Include []string `yaml:"include"`
+	// Exclude ignores the changes to the files matching these glob patterns
+	Exclude []string `yaml:"exclude"`
+	// GitIgnore ignores the changes to the files ignored by the project .gitignore
+	GitIgnore bool `yaml:"gitignore"`
+	// Debounce is the time to wait for changes to settle down before restarting
+	Debounce time.Duration `yaml:"debounce"`
+}
+
+// Validate checks that all patterns are valid
+func (o *WatchOptions) Validate() error {
+	_, err := newWatchFilter("", o)
+	return err
+}
+
+func (o *WatchOptions) debounce() time.Duration {
+	if o == nil || o.Debounce <= 0 {
+		return defaultWatchDebounce
+	}
+	return o.Debounce
+}
+
+type watchFilter struct {
+	dir       string
+	include   []*regexp.Regexp
+	exclude   []*regexp.Regexp
+	gitignore []gitignoreRule
+}
+
+type gitignoreRule struct {
+	re     *regexp.Regexp
+	negate bool
+}
+
+func newWatchFilter(dir string, opts *WatchOptions) (*watchFilter, error) {
+	f := &watchFilter{dir: dir}
+	if opts == nil {
+		return f, nil
+	}
+	for _, pattern := range opts.Include {
+		re, err := globToRegexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f.include = append(f.include, re)
+	}
+	for _, pattern := range opts.Exclude {
+		re, err := globToRegexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f.exclude = append(f.exclude, re)
+	}
+	if opts.GitIgnore && dir != "" {
+		rules, err := readGitignore(filepath.Join(dir, ".gitignore"))
+		if err != nil {
+			return nil, err
+		}
+		f.gitignore = rules
+	}
+	return f, nil
+}
+
+// Match returns true if a change to path must restart the command
+func (f *watchFilter) Match(path string) bool {
+	// ignore vim temporary files events
+	if strings.HasSuffix(filepath.Ext(path), "~") {
+		return false
+	}
+
+	rel := path
+	if f.dir != "" {
+		if r, err := filepath.Rel(f.dir, path); err == nil && !strings.HasPrefix(r, "..") {
+			rel = r
+		}
+	}
+	rel = filepath.ToSlash(rel)
+
+	for _, re := range f.exclude {
+		if re.MatchString(rel) {
+			return false
+		}
+	}
+
+	ignored := false
+	for _, rule := range f.gitignore {
+		if rule.re.MatchString(rel) {
+			ignored = !rule.negate
+		}
+	}
+	if ignored {
+		return false
+	}
+
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, re := range f.include {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// globToRegexp converts a glob pattern to a regular expression matching the
+// files and the content of the directories matching the pattern
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+	if pattern == "" {
+		return nil, errors.New("empty glob pattern")
+	}
+	if strings.HasPrefix(pattern, "/") {
+		pattern = pattern[1:]
+	} else if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+
+	var buf strings.Builder
+	buf.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				if i+2 < len(pattern) && pattern[i+2] == '/' {
+					buf.WriteString("(.*/)?")
+					i += 2
+				} else {
+					buf.WriteString(".*")
+					i++
+				}
+			} else {
+				buf.WriteString("[^/]*")
+			}
+		case '?':
+			buf.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end == -1 {
+				return nil, errors.Errorf("invalid glob pattern \"%s\": missing ]", pattern)
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			buf.WriteString("[" + class + "]")
+			i += end
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	buf.WriteString("(/.*)?$")
+
+	re, err := regexp.Compile(buf.String())
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid glob pattern \"%s\"", pattern)
+	}
+	return re, nil
+}
+
+func readGitignore(path string) ([]gitignoreRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	rules := []gitignoreRule{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := gitignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		// a pattern with a "/" (except a trailing one) is relative to the .gitignore location
+		if trimmed := strings.TrimSuffix(line, "/"); strings.Contains(trimmed, "/") && !strings.HasPrefix(trimmed, "/") {
+			line = "/" + line
+		}
+		re, err := globToRegexp(line)
+		if err != nil {
+			continue
+		}
+		rule.re = re
+		rules = append(rules, rule)
+	}
+	return rules, errors.WithStack(scanner.Err())
+}
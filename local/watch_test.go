@@ -0,0 +1,99 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package local
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type WatchSuite struct{}
+
+var _ = Suite(&WatchSuite{})
+
+func (s *WatchSuite) TestGlobToRegexp(c *C) {
+	for pattern, paths := range map[string]map[string]bool{
+		"*.php": {
+			"index.php":           true,
+			"src/Kernel.php":      true,
+			"src/Kernel.php~":     false,
+			"templates/base.twig": false,
+		},
+		"src/**/*.php": {
+			"src/Kernel.php":            true,
+			"src/Controller/Foo.php":    true,
+			"vendor/src/Controller.php": false,
+		},
+		"var/cache": {
+			"var/cache":             true,
+			"var/cache/dev/foo.php": true,
+			"var/cache.php":         false,
+			"app/var/cache/foo.php": false,
+		},
+		"/config/*.yaml": {
+			"config/services.yaml":      true,
+			"config/packages/twig.yaml": false,
+			"src/config/services.yaml":  false,
+		},
+		"test?.[ch]": {
+			"test1.c": true,
+			"test2.h": true,
+			"test.c":  false,
+		},
+	} {
+		re, err := globToRegexp(pattern)
+		c.Assert(err, IsNil)
+		for path, expected := range paths {
+			c.Check(re.MatchString(path), Equals, expected, Commentf("%s / %s", pattern, path))
+		}
+	}
+}
+
+func (s *WatchSuite) TestWatchFilter(c *C) {
+	dir := c.MkDir()
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, ".gitignore"), []byte("# comment\n/var/\n*.log\n!important.log\n"), 0644), IsNil)
+
+	f, err := newWatchFilter(dir, &WatchOptions{
+		Include:   []string{"src", "config", "*.log"},
+		Exclude:   []string{"src/Migrations"},
+		GitIgnore: true,
+	})
+	c.Assert(err, IsNil)
+	c.Check(f.Match(filepath.Join(dir, "src", "Kernel.php")), Equals, true)
+	c.Check(f.Match(filepath.Join(dir, "src", "Kernel.php~")), Equals, false)
+	c.Check(f.Match(filepath.Join(dir, "src", "Migrations", "Version1.php")), Equals, false)
+	c.Check(f.Match(filepath.Join(dir, "templates", "base.html.twig")), Equals, false)
+	c.Check(f.Match(filepath.Join(dir, "config", "var", "foo.yaml")), Equals, true)
+	c.Check(f.Match(filepath.Join(dir, "var", "cache", "dev", "foo.php")), Equals, false)
+	c.Check(f.Match(filepath.Join(dir, "src", "dev.log")), Equals, false)
+	c.Check(f.Match(filepath.Join(dir, "important.log")), Equals, true)
+
+	f, err = newWatchFilter(dir, nil)
+	c.Assert(err, IsNil)
+	c.Check(f.Match(filepath.Join(dir, "var", "cache", "dev", "foo.php")), Equals, true)
+
+	_, err = newWatchFilter(dir, &WatchOptions{Include: []string{"src/[a"}})
+	c.Assert(err, NotNil)
+}